@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/trois-six/geneparse/pkg/geneanet/dlextr"
+	"github.com/trois-six/geneparse/pkg/geneanet/utils"
+	"github.com/spf13/cobra"
+)
+
+type AccountCmd struct{}
+
+func (c *AccountCmd) Command() *cobra.Command {
+	var (
+		username     string
+		password     string
+		passwordFile string
+		config       string
+		timeout      string
+		format       string
+		session      string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "account",
+		Short: "print the information of a Geneanet account",
+		Long: `The account command will connect to Geneanet as if it was the Geneanet Android app, ` +
+			`and will print the information of the account, such as the trees it can download. ` +
+			`The session saved by the login command is reused while it is valid.`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			u, err := cmd.Flags().GetString("username")
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			p, err := cmd.Flags().GetString("password")
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			pf, err := cmd.Flags().GetString("password-file")
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			cf, err := cmd.Flags().GetString("config")
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			if u, p, err = resolveCredentials(u, p, pf, cf); err != nil {
+				return err
+			}
+
+			ts, err := cmd.Flags().GetString("timeout")
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			t, err := time.ParseDuration(ts)
+			if err != nil {
+				return fmt.Errorf("could not parse timeout: %w", err)
+			}
+
+			f, err := cmd.Flags().GetString("format")
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			s, err := cmd.Flags().GetString("session")
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			return c.Run(u, p, t, f, s)
+		},
+	}
+
+	addCredentialsFlags(cmd, &username, &password, &passwordFile, &config)
+	cmd.Flags().StringVarP(&timeout, "timeout", "t", loginTimeout, "Connection timeout for requests to Geneanet")
+	cmd.Flags().StringVarP(&format, "format", "f", formatText, "Output format: text or json")
+	addSessionFlag(cmd, &session)
+
+	return cmd
+}
+
+func printAccount(a *dlextr.AccountInfos) {
+	fmt.Printf("login:                  %s\n", a.Login)
+	fmt.Printf("privilege:              %s\n", a.Privilege)
+	fmt.Printf("tree:                   %s\n", a.Tree)
+	fmt.Printf("tree access:            %s\n", a.TreeAccess)
+	fmt.Printf("owner (proprio):        %s\n", a.Proprio)
+	fmt.Printf("can edit:               %s\n", a.CanEdit)
+	fmt.Printf("picture quota exceeded: %s\n", a.QuotaPictureExceeded)
+	fmt.Printf("anniversaries:          %d generations of ancestors, %d of descendants\n",
+		a.AnnivAsc.Int(), a.AnnivDesc.Int())
+	fmt.Println("trees:")
+
+	for _, tree := range a.Trees() {
+		fmt.Printf("  %-20s %s\n", tree.ID, tree.Label)
+	}
+}
+
+func (c *AccountCmd) Run(username, password string, timeout time.Duration, format, session string) error {
+	if err := checkFormat(format); err != nil {
+		return err
+	}
+
+	d := dlextr.New(username, password, "", timeout)
+
+	d.SetSessionFile(session)
+	d.SetPrompt(promptCredentials)
+
+	if err := d.Authenticate(); err != nil {
+		return fmt.Errorf("failed to log in: %w", err)
+	}
+
+	account, err := d.GetAccountInfos()
+	if err != nil {
+		return fmt.Errorf("failed get account infos: %w", err)
+	}
+
+	if format == formatJSON {
+		return printJSON(account)
+	}
+
+	printAccount(account)
+
+	return nil
+}
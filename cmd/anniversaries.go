@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/trois-six/geneparse/pkg/geneanet/sosa"
+	"github.com/trois-six/geneparse/pkg/geneanet/timeline"
+	"github.com/trois-six/geneparse/pkg/geneanet/tree"
+	"github.com/trois-six/geneparse/pkg/geneanet/utils"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// defaultAncestorGenerations and defaultDescendantGenerations are the
+	// default annivAsc and annivDesc settings of the Geneanet app.
+	defaultAncestorGenerations   = 4
+	defaultDescendantGenerations = 3
+)
+
+type AnniversariesCmd struct{}
+
+func (c *AnniversariesCmd) Command() *cobra.Command {
+	var (
+		inputDir string
+		format   string
+		root     int32
+		month    int32
+		day      int32
+		opts     timeline.AnniversaryOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "anniversaries",
+		Short: "list the anniversaries of the relatives of a person",
+		Long: `The anniversaries command will parse Geneanet bases downloaded by the dlextr command ` +
+			`and will list the births, marriages and deaths of the ancestors and descendants ` +
+			`of the root person which happened on a given day, today by default, or in a given month.`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			i, err := cmd.Flags().GetString("inputdir")
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			f, err := cmd.Flags().GetString("format")
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			r, err := cmd.Flags().GetInt32("root")
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			m, err := cmd.Flags().GetInt32("month")
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			d, err := cmd.Flags().GetInt32("day")
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			var o timeline.AnniversaryOptions
+
+			if o.AncestorGenerations, err = cmd.Flags().GetInt("asc"); err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			if o.DescendantGenerations, err = cmd.Flags().GetInt("desc"); err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			// without a month nor a day, the anniversaries are today's ones
+			now := time.Now()
+
+			if m == 0 {
+				m = int32(now.Month())
+
+				if d == 0 && !cmd.Flags().Changed("month") {
+					d = int32(now.Day())
+				}
+			}
+
+			return c.Run(i, f, r, o, m, d)
+		},
+	}
+
+	cmd.Flags().StringVarP(&inputDir, "inputdir", "i", "output", "Input directory for Geneanet bases")
+	cmd.Flags().StringVarP(&format, "format", "f", formatText, "Output format: text or json")
+	cmd.Flags().Int32VarP(&root, "root", "r", rootFromBase, "Index of the root person (default: root person of the base)")
+	cmd.Flags().Int32VarP(&month, "month", "m", 0, "Month of the anniversaries (default: current month)")
+	cmd.Flags().Int32VarP(&day, "day", "d", 0, "Day of the anniversaries, 0 for the whole month (default: today)")
+	cmd.Flags().IntVar(&opts.AncestorGenerations, "asc", defaultAncestorGenerations,
+		"Generations of ancestors to list, as the annivAsc account setting (0: none, -1: all)")
+	cmd.Flags().IntVar(&opts.DescendantGenerations, "desc", defaultDescendantGenerations,
+		"Generations of descendants to list, as the annivDesc account setting (0: none, -1: all)")
+
+	return cmd
+}
+
+func (c *AnniversariesCmd) Run(
+	inputDir, format string,
+	root int32,
+	opts timeline.AnniversaryOptions,
+	month, day int32,
+) error {
+	if err := checkFormat(format); err != nil {
+		return err
+	}
+
+	if err := checkInputDir(inputDir); err != nil {
+		return err
+	}
+
+	t, err := tree.Load(inputDir)
+	if err != nil {
+		return fmt.Errorf("failed to load tree: %w", err)
+	}
+
+	if root == rootFromBase {
+		root = sosa.Root(t)
+	}
+
+	anniversaries, err := timeline.Anniversaries(t, root, opts, month, day)
+	if err != nil {
+		return fmt.Errorf("failed to list anniversaries: %w", err)
+	}
+
+	if format == formatJSON {
+		return printJSON(anniversaries)
+	}
+
+	for _, a := range anniversaries {
+		names := make([]string, 0, len(a.Persons))
+
+		for _, i := range a.Persons {
+			names = append(names, personString(t.Person(i)))
+		}
+
+		fmt.Printf("%02d/%02d %d (%d years ago): %s of %s", a.Day, a.Month, a.Year,
+			int32(time.Now().Year())-a.Year, a.Event, strings.Join(names, " and "))
+
+		if a.Place != "" {
+			fmt.Printf(", %s", a.Place)
+		}
+
+		fmt.Println()
+	}
+
+	fmt.Printf("%d anniversary(ies) found\n", len(anniversaries))
+
+	return nil
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/trois-six/geneparse/pkg/geneanet/check"
+	"github.com/trois-six/geneparse/pkg/geneanet/database"
+	"github.com/trois-six/geneparse/pkg/geneanet/utils"
+	"github.com/spf13/cobra"
+)
+
+type CheckCmd struct{}
+
+func (c *CheckCmd) Command() *cobra.Command {
+	var (
+		inputDir string
+		format   string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "check",
+		Short: "check the consistency of Geneanet bases",
+		Long: `The check command will parse Geneanet bases downloaded by the dlextr command ` +
+			`and will report inconsistent dates and broken links between persons and families.`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			i, err := cmd.Flags().GetString("inputdir")
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			f, err := cmd.Flags().GetString("format")
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			return c.Run(i, f)
+		},
+	}
+
+	cmd.Flags().StringVarP(&inputDir, "inputdir", "i", "output", "Input directory for Geneanet bases")
+	cmd.Flags().StringVarP(&format, "format", "f", formatText, "Output format: text or json")
+
+	return cmd
+}
+
+func (c *CheckCmd) Run(inputDir, format string) error {
+	if err := checkFormat(format); err != nil {
+		return err
+	}
+
+	if err := checkInputDir(inputDir); err != nil {
+		return err
+	}
+
+	person := database.NewPerson(inputDir)
+	family := database.NewFamily(inputDir)
+
+	if err := database.PopulateDatabases([]database.Database{person, family}); err != nil {
+		return fmt.Errorf("failed to load databases: %w", err)
+	}
+
+	issues := check.Check(person.GetPersons(), family.GetFamilies())
+
+	if format == formatJSON {
+		if issues == nil {
+			issues = []check.Issue{}
+		}
+
+		return printJSON(issues)
+	}
+
+	for _, issue := range issues {
+		fmt.Printf("[%s] %s\n", issue.Kind, issue.Message)
+	}
+
+	fmt.Printf("%d issue(s) found\n", len(issues))
+
+	return nil
+}
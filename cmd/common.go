@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/trois-six/geneparse/pkg/geneanet/utils"
+)
+
+const (
+	formatText = "text"
+	formatJSON = "json"
+)
+
+var errUnknownFormat = errors.New("unknown output format")
+
+func checkFormat(format string) error {
+	if format != formatText && format != formatJSON {
+		return fmt.Errorf("%w: %s", errUnknownFormat, format)
+	}
+
+	return nil
+}
+
+func checkInputDir(inputDir string) error {
+	info, err := os.Stat(inputDir)
+	if err != nil {
+		return fmt.Errorf("input directory does not exist: %w", err)
+	} else if !info.IsDir() {
+		return fmt.Errorf("%w: %s", utils.ErrDirMustBeADir, inputDir)
+	}
+
+	return nil
+}
+
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+
+	if err := enc.Encode(v); err != nil {
+		return fmt.Errorf("could not encode json: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/trois-six/geneparse/pkg/geneanet/consanguinity"
+	"github.com/trois-six/geneparse/pkg/geneanet/tree"
+	"github.com/trois-six/geneparse/pkg/geneanet/utils"
+	"github.com/spf13/cobra"
+)
+
+const percent = 100
+
+type ConsanguinityCmd struct{}
+
+type inbredPerson struct {
+	Person      int32   `json:"person"`
+	Firstname   string  `json:"firstname"`
+	Lastname    string  `json:"lastname"`
+	Coefficient float64 `json:"coefficient"`
+}
+
+func (c *ConsanguinityCmd) Command() *cobra.Command {
+	var (
+		inputDir string
+		format   string
+		min      float64
+	)
+
+	cmd := &cobra.Command{
+		Use:   "consanguinity",
+		Short: "compute the inbreeding coefficients of the persons",
+		Long: `The consanguinity command will parse Geneanet bases downloaded by the dlextr command ` +
+			`and will list the persons whose Wright's inbreeding coefficient is greater than the minimum.`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			i, err := cmd.Flags().GetString("inputdir")
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			f, err := cmd.Flags().GetString("format")
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			m, err := cmd.Flags().GetFloat64("min")
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			return c.Run(i, f, m)
+		},
+	}
+
+	cmd.Flags().StringVarP(&inputDir, "inputdir", "i", "output", "Input directory for Geneanet bases")
+	cmd.Flags().StringVarP(&format, "format", "f", formatText, "Output format: text or json")
+	cmd.Flags().Float64VarP(&min, "min", "m", 0, "Only list the persons whose coefficient is greater than this one")
+
+	return cmd
+}
+
+func (c *ConsanguinityCmd) Run(inputDir, format string, min float64) error {
+	if err := checkFormat(format); err != nil {
+		return err
+	}
+
+	if err := checkInputDir(inputDir); err != nil {
+		return err
+	}
+
+	t, err := tree.Load(inputDir)
+	if err != nil {
+		return fmt.Errorf("failed to load tree: %w", err)
+	}
+
+	coefficients, err := consanguinity.Compute(t)
+	if err != nil {
+		return fmt.Errorf("failed to compute inbreeding coefficients: %w", err)
+	}
+
+	inbred := []inbredPerson{}
+
+	for i, coefficient := range coefficients {
+		if coefficient > min {
+			p := t.Person(int32(i))
+			inbred = append(inbred, inbredPerson{
+				Person:      p.GetIndex(),
+				Firstname:   p.GetFirstname(),
+				Lastname:    p.GetLastname(),
+				Coefficient: coefficient,
+			})
+		}
+	}
+
+	if format == formatJSON {
+		return printJSON(inbred)
+	}
+
+	for _, p := range inbred {
+		fmt.Printf("%s %s (%d): %g (%.2f%%)\n", p.Firstname, p.Lastname, p.Person, p.Coefficient, p.Coefficient*percent)
+	}
+
+	fmt.Printf("%d inbred person(s) found\n", len(inbred))
+
+	return nil
+}
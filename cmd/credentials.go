@@ -0,0 +1,205 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/trois-six/geneparse/pkg/geneanet/utils"
+	"golang.org/x/term"
+	"github.com/spf13/cobra"
+)
+
+const (
+	envUsername = "GENEPARSE_USERNAME"
+	envPassword = "GENEPARSE_PASSWORD"
+	configDir   = "geneparse"
+	configFile  = "config.json"
+)
+
+// credentials are the username and the password to log in to Geneanet, as
+// read from the config file.
+type credentials struct {
+	Username     string `json:"username"`
+	Password     string `json:"password"`
+	PasswordFile string `json:"passwordFile"`
+}
+
+// defaultConfigFile returns the config file used when --config is not given,
+// none if the configuration directory of the user is unknown.
+func defaultConfigFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, configDir, configFile)
+}
+
+func addCredentialsFlags(cmd *cobra.Command, username, password, passwordFile, config *string) {
+	cmd.Flags().StringVarP(username, "username", "u", "",
+		"Username or email address to log in to Geneanet, also read from "+envUsername)
+	cmd.Flags().StringVarP(password, "password", "p", "",
+		"Password to log in to Geneanet, visible to the other users: prefer "+envPassword+" or --password-file")
+	cmd.Flags().StringVar(passwordFile, "password-file", "", "File holding the password to log in to Geneanet")
+	cmd.Flags().StringVar(config, "config", defaultConfigFile(),
+		"JSON config file holding the username, and the password or the passwordFile, to log in to Geneanet")
+}
+
+// readPasswordFile returns the first line of the password file.
+func readPasswordFile(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("could not read password file: %w", err)
+	}
+
+	password := strings.SplitN(string(content), "\n", 2)[0] //nolint:gomnd
+
+	return strings.TrimSuffix(password, "\r"), nil
+}
+
+// readConfig returns the credentials of the config file, none if it does not
+// exist.
+func readConfig(path string) (credentials, error) {
+	var creds credentials
+
+	if path == "" {
+		return creds, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return creds, nil
+	} else if err != nil {
+		return creds, fmt.Errorf("could not read config file: %w", err)
+	}
+
+	if err = json.Unmarshal(content, &creds); err != nil {
+		return creds, fmt.Errorf("%w: %s: %v", utils.ErrFileMalFormatted, path, err)
+	}
+
+	if creds.Password != "" {
+		if info, err := os.Stat(path); err == nil && info.Mode().Perm()&0o077 != 0 {
+			log.Printf("Warning: config file %s holding a password is readable by other users", path)
+		}
+	}
+
+	return creds, nil
+}
+
+// resolveCredentials returns the username and the password given on the
+// command line, in the environment, in the password file or in the config
+// file, in that order. They are empty when not found, to reuse the saved
+// session or to prompt for them.
+func resolveCredentials(username, password, passwordFile, config string) (string, string, error) {
+	if username == "" {
+		username = os.Getenv(envUsername)
+	}
+
+	if password == "" {
+		password = os.Getenv(envPassword)
+	}
+
+	if password == "" && passwordFile != "" {
+		p, err := readPasswordFile(passwordFile)
+		if err != nil {
+			return "", "", err
+		}
+
+		password = p
+	}
+
+	if username != "" && password != "" {
+		return username, password, nil
+	}
+
+	creds, err := readConfig(config)
+	if err != nil {
+		return "", "", err
+	}
+
+	// The password of the config file is the one of its username only.
+	if username != "" && creds.Username != "" && creds.Username != username {
+		return username, password, nil
+	}
+
+	if username == "" {
+		username = creds.Username
+	}
+
+	if password == "" && creds.Password != "" {
+		password = creds.Password
+	}
+
+	if password == "" && creds.PasswordFile != "" {
+		if password, err = readPasswordFile(creds.PasswordFile); err != nil {
+			return "", "", err
+		}
+	}
+
+	return username, password, nil
+}
+
+// isTerminal tells whether the standard input is a terminal, whose echo can be
+// disabled to read a password.
+func isTerminal() bool {
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil && !(errors.Is(err, io.EOF) && line != "") {
+		return "", fmt.Errorf("could not read input: %w", err)
+	}
+
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// readPassword reads a line from the terminal without echoing it.
+func readPassword() (string, error) {
+	password, err := term.ReadPassword(int(os.Stdin.Fd()))
+
+	fmt.Fprintln(os.Stderr)
+
+	if err != nil {
+		return "", fmt.Errorf("could not read password: %w", err)
+	}
+
+	return string(password), nil
+}
+
+// promptCredentials asks the password, and the username if unknown, when the
+// standard input is a terminal.
+func promptCredentials(username string) (string, string, error) {
+	if !isTerminal() {
+		return username, "", nil
+	}
+
+	r := bufio.NewReader(os.Stdin)
+
+	if username == "" {
+		fmt.Fprint(os.Stderr, "Geneanet username: ")
+
+		u, err := readLine(r)
+		if err != nil {
+			return "", "", err
+		}
+
+		username = u
+	}
+
+	fmt.Fprintf(os.Stderr, "Geneanet password for %s: ", username)
+
+	password, err := readPassword()
+	if err != nil {
+		return "", "", err
+	}
+
+	return username, password, nil
+}
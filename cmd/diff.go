@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/trois-six/geneparse/pkg/geneanet/diff"
+	"github.com/trois-six/geneparse/pkg/geneanet/tree"
+	"github.com/trois-six/geneparse/pkg/geneanet/utils"
+	"github.com/spf13/cobra"
+)
+
+type DiffCmd struct{}
+
+func (c *DiffCmd) Command() *cobra.Command {
+	var format string
+
+	cmd := &cobra.Command{
+		Use:   "diff <dirA> <dirB>",
+		Short: "report the changes between two Geneanet bases",
+		Long: `The diff command will parse two Geneanet bases downloaded by the dlextr command, ` +
+			`such as two snapshots of a tree, and will report the persons and the families added, ` +
+			`removed or modified from the first one to the second one. Records are matched by their ` +
+			`index and their name and occurrence number, and the changes of their names, dates, ` +
+			`places, events and notes are listed.`,
+		Args: cobra.ExactArgs(2), //nolint:gomnd
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			f, err := cmd.Flags().GetString("format")
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			return c.Run(args[0], args[1], f)
+		},
+	}
+
+	cmd.Flags().StringVarP(&format, "format", "f", formatText, "Output format: text or json")
+
+	return cmd
+}
+
+func printIndexes(r diff.Record) string {
+	switch {
+	case r.OldIndex == nil:
+		return fmt.Sprintf("%d", *r.NewIndex)
+	case r.NewIndex == nil || *r.OldIndex == *r.NewIndex:
+		return fmt.Sprintf("%d", *r.OldIndex)
+	default:
+		return fmt.Sprintf("%d -> %d", *r.OldIndex, *r.NewIndex)
+	}
+}
+
+func printRecords(kind string, records diff.Records) {
+	fmt.Printf("%s: %d added, %d removed, %d modified\n",
+		kind, len(records.Added), len(records.Removed), len(records.Modified))
+
+	for _, r := range records.Added {
+		fmt.Printf("+ %s [%s]\n", r.Name, printIndexes(r))
+	}
+
+	for _, r := range records.Removed {
+		fmt.Printf("- %s [%s]\n", r.Name, printIndexes(r))
+	}
+
+	for _, r := range records.Modified {
+		fmt.Printf("~ %s [%s]\n", r.Name, printIndexes(r))
+
+		for _, change := range r.Changes {
+			switch {
+			case change.Old == "":
+				fmt.Printf("    + %s: %q\n", change.Field, change.New)
+			case change.New == "":
+				fmt.Printf("    - %s: %q\n", change.Field, change.Old)
+			default:
+				fmt.Printf("    %s: %q -> %q\n", change.Field, change.Old, change.New)
+			}
+		}
+	}
+}
+
+func (c *DiffCmd) Run(dirA, dirB, format string) error {
+	if err := checkFormat(format); err != nil {
+		return err
+	}
+
+	trees := make([]*tree.Tree, 0, 2) //nolint:gomnd
+
+	for _, dir := range []string{dirA, dirB} {
+		if err := checkInputDir(dir); err != nil {
+			return err
+		}
+
+		t, err := tree.Load(dir)
+		if err != nil {
+			return fmt.Errorf("failed to load tree %s: %w", dir, err)
+		}
+
+		trees = append(trees, t)
+	}
+
+	d := diff.Compare(trees[0], trees[1])
+
+	if format == formatJSON {
+		return printJSON(d)
+	}
+
+	printRecords("persons", d.Persons)
+	printRecords("families", d.Families)
+
+	return nil
+}
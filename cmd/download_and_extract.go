@@ -1,11 +1,17 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
+	"log"
 	"os"
+	"path/filepath"
+	"sort"
 	"time"
 
+	"github.com/trois-six/geneparse/pkg/geneanet/database"
 	"github.com/trois-six/geneparse/pkg/geneanet/dlextr"
+	"github.com/trois-six/geneparse/pkg/geneanet/snapshot"
 	"github.com/trois-six/geneparse/pkg/geneanet/utils"
 	"github.com/spf13/cobra"
 )
@@ -16,17 +22,44 @@ type DownloadAndExtractCmd struct{}
 
 func (c *DownloadAndExtractCmd) Command() *cobra.Command {
 	var (
-		username  string
-		password  string
-		outputDir string
-		timeout   string
+		username     string
+		password     string
+		passwordFile string
+		config       string
+		outputDir    string
+		timeout      string
+		maxDuration  string
+		retries      int
+		maxBackoff   string
+		interval     string
+		trees        []string
+		full         bool
+		snapshots    bool
+		checkAccount bool
+		keep         int
+		session      string
 	)
 
 	cmd := &cobra.Command{
 		Use:   "dlextr",
 		Short: "download and extract Geneanet bases",
 		Long: `The dlextr command will connect to Geneanet as if it was the Geneanet Android app, ` +
-			`and will download the Geneanet bases. These bases use the Geneweb format.`,
+			`and will download the Geneanet bases. These bases use the Geneweb format. ` +
+			`By default, the tree of the account is extracted into the output directory; ` +
+			`with --trees, each selected tree is extracted into its own subdirectory, named after the ` +
+			`login for the tree of the account. Selecting another tree than the one of the account is not ` +
+			`known to be supported by Geneanet: such a tree is always downloaded in full, and the download ` +
+			`fails unless its base can be told from the one of the account, which must be selected too, ` +
+			`extracted already or downloaded with --check-account-tree. ` +
+			`The timestamp of the tree of the account already extracted is sent to Geneanet for it to ` +
+			`send nothing when the tree did not change, which is not known to be supported either: a ` +
+			`download which does not hold a complete base is done again in full. A tree is only extracted ` +
+			`again when its timestamp changed, and its previous version is kept in the "` +
+			dlextr.PreviousDir + `" subdirectory. ` +
+			`With --snapshots, each version of a tree is instead kept in <outputdir>/<tree>/<timestamp>, ` +
+			`<outputdir>/<tree>/` + snapshot.Latest + ` pointing to the newest one. ` +
+			`The session saved by the login command is reused while it is valid; otherwise the ` +
+			`username and the password are read as by the login command.`,
 		RunE: func(cmd *cobra.Command, args []string) (err error) {
 			u, err := cmd.Flags().GetString("username")
 			if err != nil {
@@ -38,6 +71,20 @@ func (c *DownloadAndExtractCmd) Command() *cobra.Command {
 				return fmt.Errorf(utils.ErrParseInput, err)
 			}
 
+			pf, err := cmd.Flags().GetString("password-file")
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			cf, err := cmd.Flags().GetString("config")
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			if u, p, err = resolveCredentials(u, p, pf, cf); err != nil {
+				return err
+			}
+
 			o, err := cmd.Flags().GetString("outputdir")
 			if err != nil {
 				return fmt.Errorf(utils.ErrParseInput, err)
@@ -53,27 +100,109 @@ func (c *DownloadAndExtractCmd) Command() *cobra.Command {
 				return fmt.Errorf("could not parse timeout: %w", err)
 			}
 
-			return c.Run(u, p, o, t)
-		},
-	}
+			ms, err := cmd.Flags().GetString("max-duration")
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
 
-	cmd.Flags().StringVarP(&username, "username", "u", "", "Username or email address to log in to Geneanet (required)")
-	cmd.Flags().StringVarP(&password, "password", "p", "", "Password to log in to Geneanet (required)")
-	cmd.Flags().StringVarP(&outputDir, "outputdir", "o", "output", "Output directory for Geneanet bases")
-	cmd.Flags().StringVarP(&timeout, "timeout", "t", loginTimeout, "Connection timeout for requests to Geneanet")
+			m, err := time.ParseDuration(ms)
+			if err != nil {
+				return fmt.Errorf("could not parse max duration: %w", err)
+			}
 
-	if err := cmd.MarkFlagRequired("username"); err != nil {
-		return nil
-	}
+			r, err := cmd.Flags().GetInt("retries")
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
 
-	if err := cmd.MarkFlagRequired("password"); err != nil {
-		return nil
+			bs, err := cmd.Flags().GetString("max-backoff")
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			b, err := time.ParseDuration(bs)
+			if err != nil {
+				return fmt.Errorf("could not parse max backoff: %w", err)
+			}
+
+			is, err := cmd.Flags().GetString("rate-interval")
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			i, err := time.ParseDuration(is)
+			if err != nil {
+				return fmt.Errorf("could not parse rate interval: %w", err)
+			}
+
+			retry := dlextr.RetryOptions{Retries: r, MinBackoff: dlextr.DefaultMinBackoff, MaxBackoff: b, Interval: i}
+
+			trs, err := cmd.Flags().GetStringSlice("trees")
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			f, err := cmd.Flags().GetBool("full")
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			s, err := cmd.Flags().GetBool("snapshots")
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			ca, err := cmd.Flags().GetBool("check-account-tree")
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			k, err := cmd.Flags().GetInt("keep")
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			ss, err := cmd.Flags().GetString("session")
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			return c.Run(u, p, ss, o, t, m, retry, trs, f, s, ca, k)
+		},
 	}
 
+	addCredentialsFlags(cmd, &username, &password, &passwordFile, &config)
+	addSessionFlag(cmd, &session)
+	cmd.Flags().StringVarP(&outputDir, "outputdir", "o", "output", "Output directory for Geneanet bases")
+	cmd.Flags().StringVarP(&timeout, "timeout", "t", loginTimeout,
+		"Connection timeout for requests to Geneanet, and idle timeout for the downloads")
+	cmd.Flags().StringVar(&maxDuration, "max-duration", "0s", "Maximum duration of the download of a tree, 0 for no limit")
+	cmd.Flags().IntVar(&retries, "retries", dlextr.DefaultRetries,
+		"Number of retries of a request failing with a network error or a 429 or 5xx status")
+	cmd.Flags().StringVar(&maxBackoff, "max-backoff", dlextr.DefaultMaxBackoff.String(),
+		"Maximum delay between two retries, a longer Retry-After asked by Geneanet failing the request")
+	cmd.Flags().StringVar(&interval, "rate-interval", dlextr.DefaultInterval.String(),
+		"Minimum interval between two requests to Geneanet")
+	cmd.Flags().StringSliceVar(&trees, "trees", nil,
+		"Ids of the trees to download, as listed by the account command, or \""+dlextr.AllTrees+"\"")
+	cmd.Flags().BoolVar(&full, "full", false, "Download the trees even if they did not change since the last download")
+	cmd.Flags().BoolVar(&checkAccount, "check-account-tree", false,
+		"Download the tree of the account when it is not selected, to check the other trees against its latest version "+
+			"instead of the extracted one")
+	cmd.Flags().BoolVar(&snapshots, "snapshots", false, "Keep each version of the trees in a snapshot named after its timestamp")
+	cmd.Flags().IntVar(&keep, "keep", snapshot.DefaultKeep, "Number of snapshots to keep for each tree, 0 to keep them all")
+
 	return cmd
 }
 
-func (c *DownloadAndExtractCmd) Run(username, password, outputDir string, timeout time.Duration) error {
+func (c *DownloadAndExtractCmd) Run(
+	username, password, session, outputDir string,
+	timeout, maxDuration time.Duration,
+	retry dlextr.RetryOptions,
+	trees []string,
+	full, snapshots, checkAccount bool,
+	keep int,
+) error {
 	info, err := os.Stat(outputDir)
 	if err != nil {
 		if !os.IsNotExist(err) {
@@ -88,26 +217,209 @@ func (c *DownloadAndExtractCmd) Run(username, password, outputDir string, timeou
 	}
 
 	d := dlextr.New(username, password, outputDir, timeout)
+	d.SetMaxDuration(maxDuration)
+	d.SetRetry(retry)
+
+	defer d.Close()
 
-	if err := d.Login(); err != nil {
+	d.SetSessionFile(session)
+	d.SetPrompt(promptCredentials)
+
+	if err := d.Authenticate(); err != nil {
 		return fmt.Errorf("failed to log in: %w", err)
 	}
 
-	if err := d.GetAccountInfos(); err != nil {
-		return fmt.Errorf("failed get account infos: %w", err)
+	// The account infos are only needed to select the trees and name the
+	// snapshots: the tree of the account is downloaded without them.
+	account, err := d.GetAccountInfos()
+	if err != nil {
+		if len(trees) > 0 || snapshots {
+			return fmt.Errorf("failed get account infos: %w", err)
+		}
+
+		log.Printf("Could not read account infos: %v", err)
+	} else if out, err := json.MarshalIndent(account, "", "  "); err == nil {
+		log.Printf("Account infos:\n%s", out)
+	}
+
+	var store *snapshot.Store
+	if snapshots {
+		store = snapshot.New(outputDir)
+	}
+
+	if len(trees) == 0 {
+		if store != nil {
+			return snapshotTree(d, store, "", string(account.Login), full, keep)
+		}
+
+		return downloadTree(d, outputDir, "", "", full)
+	}
+
+	selected, err := account.SelectTrees(trees)
+	if err != nil {
+		return fmt.Errorf("failed to select trees: %w", err)
+	}
+
+	// The tree of the account, kept in the directory named after the login,
+	// goes first for the other trees to be checked against it.
+	main, login := account.MainTree(), string(account.Login)
+
+	sort.SliceStable(selected, func(i, j int) bool {
+		return selected[i].ID == main.ID && selected[j].ID != main.ID
+	})
+
+	if err = accountBase(d, store, outputDir, login, checkAccount && selected[0].ID != main.ID); err != nil {
+		return fmt.Errorf("tree %s: %w", main.ID, err)
+	}
+
+	for _, tree := range selected {
+		id, dir := tree.ID, tree.ID
+		if id == main.ID {
+			id, dir = "", login
+		}
+
+		log.Printf("Downloading tree %s (%s)", tree.ID, tree.Label)
+
+		if store != nil {
+			err = snapshotTree(d, store, id, dir, full, keep)
+		} else {
+			err = downloadTree(d, outputDir, id, dir, full)
+		}
+
+		if err != nil {
+			return fmt.Errorf("tree %s: %w", tree.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// accountBase sets the base info of the local version of the tree of the
+// account, so that GetBase fails when Geneanet sends it for another tree, or
+// when it is unknown. The tree is only downloaded, to check the other trees
+// against its latest version, when fetch is set.
+func accountBase(d *dlextr.Download, store *snapshot.Store, outputDir, login string, fetch bool) error {
+	var (
+		info *database.BaseInfo
+		err  error
+	)
+
+	if store != nil {
+		var latest *snapshot.Snapshot
+		if latest, err = store.Latest(login); err == nil {
+			info, err = database.ReadInfoBase(latest.Dir)
+		}
+	} else if info, err = database.ReadInfoBase(filepath.Join(outputDir, login)); err != nil {
+		info, err = database.ReadInfoBase(outputDir)
+	}
+
+	var timestamp int64
+
+	if err == nil {
+		d.SetBaseInfo("", info)
+		timestamp = info.Timestamp
 	}
 
-	if err := d.SetLogged(); err != nil {
-		return fmt.Errorf("failed set as logged: %w", err)
+	if !fetch {
+		return nil
 	}
 
-	if err := d.GetBase(); err != nil {
+	log.Printf("Checking tree %s, to tell it from the other trees", login)
+
+	if err = d.GetBase("", timestamp); err != nil {
 		return fmt.Errorf("failed to download the Geneanet bases: %w", err)
 	}
 
-	if err := d.Unzip(); err != nil {
+	return d.Close()
+}
+
+// fetchTree downloads the tree of the given id, the one of the account when id
+// is empty, and tells whether it changed since the local version of the given
+// timestamp, 0 when there is none.
+func fetchTree(d *dlextr.Download, id string, timestamp int64) (bool, error) {
+	if err := d.GetBase(id, timestamp); err != nil {
+		return false, fmt.Errorf("failed to download the Geneanet bases: %w", err)
+	}
+
+	changed, err := d.Changed(timestamp)
+	if err != nil {
+		return false, fmt.Errorf("failed to check the Geneanet bases: %w", err)
+	}
+
+	return changed, nil
+}
+
+// downloadTree downloads the tree of the given id and extracts it into the
+// subdir directory of the output directory, unless the tree already there is
+// up to date and full is not set.
+func downloadTree(d *dlextr.Download, outputDir, id, subdir string, full bool) error {
+	var timestamp int64
+
+	if !full {
+		if info, err := database.ReadInfoBase(filepath.Join(outputDir, subdir)); err == nil {
+			timestamp = info.Timestamp
+		}
+	}
+
+	changed, err := fetchTree(d, id, timestamp)
+	if err != nil {
+		return err
+	}
+
+	if !changed {
+		log.Printf("Bases in %s are up to date (%s)", filepath.Join(outputDir, subdir), time.Unix(timestamp, 0))
+
+		return nil
+	}
+
+	if err := d.Unzip(subdir); err != nil {
+		return fmt.Errorf("failed to extract the Geneanet bases: %w", err)
+	}
+
+	return nil
+}
+
+// snapshotTree downloads the tree of the given id and stores it as a new
+// snapshot of the tree, unless its latest snapshot is up to date and full is
+// not set, then prunes its snapshots to keep the given number of them.
+func snapshotTree(d *dlextr.Download, store *snapshot.Store, id, tree string, full bool, keep int) error {
+	var timestamp int64
+
+	if latest, err := store.Latest(tree); err == nil && !full {
+		timestamp = latest.Timestamp
+	}
+
+	changed, err := fetchTree(d, id, timestamp)
+	if err != nil {
+		return err
+	}
+
+	if !changed {
+		log.Printf("Latest snapshot of %s is up to date (%s)", tree, time.Unix(timestamp, 0))
+
+		return nil
+	}
+
+	info, err := d.BaseInfo()
+	if err != nil {
+		return fmt.Errorf("failed to read the Geneanet bases info: %w", err)
+	}
+
+	s, err := store.Add(tree, info.Timestamp, d.UnzipTo)
+	if err != nil {
 		return fmt.Errorf("failed to extract the Geneanet bases: %w", err)
 	}
 
+	log.Printf("Stored snapshot %s", s.Dir)
+
+	removed, err := store.Prune(tree, keep)
+	if err != nil {
+		return fmt.Errorf("failed to prune snapshots: %w", err)
+	}
+
+	for _, r := range removed {
+		log.Printf("Removed snapshot %s", r.Dir)
+	}
+
 	return nil
 }
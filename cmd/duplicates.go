@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/trois-six/geneparse/pkg/geneanet/duplicate"
+	"github.com/trois-six/geneparse/pkg/geneanet/tree"
+	"github.com/trois-six/geneparse/pkg/geneanet/utils"
+	"github.com/spf13/cobra"
+)
+
+type DuplicatesCmd struct{}
+
+func (c *DuplicatesCmd) Command() *cobra.Command {
+	var (
+		inputDir   string
+		format     string
+		threshold  float64
+		maxYearGap int32
+	)
+
+	cmd := &cobra.Command{
+		Use:   "duplicates",
+		Short: "find the persons which may be duplicates",
+		Long: `The duplicates command will parse Geneanet bases downloaded by the dlextr command ` +
+			`and will list the pairs of persons which may be the same, scored from their names, ` +
+			`their birth and death years, their parents and their spouses.`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			i, err := cmd.Flags().GetString("inputdir")
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			f, err := cmd.Flags().GetString("format")
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			t, err := cmd.Flags().GetFloat64("threshold")
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			g, err := cmd.Flags().GetInt32("max-year-gap")
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			return c.Run(i, f, duplicate.Options{Threshold: t, MaxYearGap: g})
+		},
+	}
+
+	cmd.Flags().StringVarP(&inputDir, "inputdir", "i", "output", "Input directory for Geneanet bases")
+	cmd.Flags().StringVarP(&format, "format", "f", formatText, "Output format: text or json")
+	cmd.Flags().Float64VarP(&threshold, "threshold", "t", duplicate.DefaultThreshold,
+		"Minimum score, between 0 and 1, of the reported pairs")
+	cmd.Flags().Int32VarP(&maxYearGap, "max-year-gap", "g", duplicate.DefaultMaxYearGap,
+		"Maximum difference between the birth or death years of two duplicates")
+
+	return cmd
+}
+
+func (c *DuplicatesCmd) Run(inputDir, format string, opts duplicate.Options) error {
+	if err := checkFormat(format); err != nil {
+		return err
+	}
+
+	if err := checkInputDir(inputDir); err != nil {
+		return err
+	}
+
+	t, err := tree.Load(inputDir)
+	if err != nil {
+		return fmt.Errorf("failed to load tree: %w", err)
+	}
+
+	candidates := duplicate.Find(t, opts)
+
+	if format == formatJSON {
+		if candidates == nil {
+			candidates = []duplicate.Candidate{}
+		}
+
+		return printJSON(candidates)
+	}
+
+	for _, candidate := range candidates {
+		fmt.Printf("%.2f %s <-> %s: %s\n", candidate.Score,
+			personString(t.Person(candidate.A)), personString(t.Person(candidate.B)),
+			strings.Join(candidate.Reasons, ", "))
+	}
+
+	fmt.Printf("%d candidate pair(s) found\n", len(candidates))
+
+	return nil
+}
@@ -2,34 +2,98 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/trois-six/geneparse/pkg/geneanet"
+	"github.com/trois-six/geneparse/pkg/geneanet/tree"
 	"github.com/trois-six/geneparse/pkg/geneanet/utils"
 	"github.com/spf13/cobra"
 )
 
+const noSubtree = -1
+
 type GedcomCmd struct{}
 
 func (c *GedcomCmd) Command() *cobra.Command {
-	var inputDir string
+	var (
+		inputDir          string
+		output            string
+		withSosa          bool
+		withConsanguinity bool
+		root              int32
+		subtree           tree.SubtreeOptions
+	)
 
 	cmd := &cobra.Command{
 		Use:   "gedcom",
 		Short: "parse Geneanet bases and create a gedcom file",
 		Long: `The gedcom command will parse Geneanet bases downloaded by the dlextr command ` +
-			`and will create the corresponding gedcom file.`,
+			`and will create the corresponding gedcom file. The persons and the families are streamed ` +
+			`to the file, but --sosa, --consanguinity and --root load the whole base in memory first.`,
 		RunE: func(cmd *cobra.Command, args []string) (err error) {
 			i, err := cmd.Flags().GetString("inputdir")
 			if err != nil {
 				return fmt.Errorf("could not parse input: %w", err)
 			}
 
-			return c.Run(i)
+			o, err := cmd.Flags().GetString("output")
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			s, err := cmd.Flags().GetBool("sosa")
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			k, err := cmd.Flags().GetBool("consanguinity")
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			r, err := cmd.Flags().GetInt32("root")
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			var opts tree.SubtreeOptions
+
+			if opts.Ancestors, err = cmd.Flags().GetBool("ancestors"); err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			if opts.Descendants, err = cmd.Flags().GetBool("descendants"); err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			if opts.Generations, err = cmd.Flags().GetInt("generations"); err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			if opts.Spouses, err = cmd.Flags().GetBool("spouses"); err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			if opts.Siblings, err = cmd.Flags().GetBool("siblings"); err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			return c.Run(i, o, s, k, r, opts)
 		},
 	}
 
 	cmd.Flags().StringVarP(&inputDir, "inputdir", "i", "output", "Input directory for Geneanet bases")
+	cmd.Flags().StringVarP(&output, "output", "o", geneanet.DefaultOutput, "Gedcom file to create")
+	cmd.Flags().BoolVarP(&withSosa, "sosa", "s", false, "Add the Sosa numbers of the root person ancestors as _SOSA tags")
+	cmd.Flags().BoolVarP(&withConsanguinity, "consanguinity", "c", false,
+		"Add the inbreeding coefficients of the inbred persons as _CONSANG tags")
+	cmd.Flags().Int32VarP(&root, "root", "r", noSubtree, "Only export the branch around the person of this index")
+	cmd.Flags().BoolVarP(&subtree.Ancestors, "ancestors", "a", false, "Export the ancestors of the root (default with --root)")
+	cmd.Flags().BoolVarP(&subtree.Descendants, "descendants", "d", false,
+		"Export the descendants of the root (default with --root)")
+	cmd.Flags().IntVarP(&subtree.Generations, "generations", "g", 0,
+		"Maximum number of generations around the root (default: all)")
+	cmd.Flags().BoolVar(&subtree.Spouses, "spouses", false, "Also export the spouses of the exported persons")
+	cmd.Flags().BoolVar(&subtree.Siblings, "siblings", false, "Also export the siblings of the exported persons")
 
 	if err := cmd.MarkFlagRequired("inputdir"); err != nil {
 		return nil
@@ -38,12 +102,14 @@ func (c *GedcomCmd) Command() *cobra.Command {
 	return cmd
 }
 
-func (c *GedcomCmd) Run(inputDir string) error {
-	info, err := os.Stat(inputDir)
-	if err != nil {
-		return fmt.Errorf("input directory does not exist: %w", err)
-	} else if !info.IsDir() {
-		return fmt.Errorf("%w: %s", utils.ErrDirMustBeADir, inputDir)
+func (c *GedcomCmd) Run(
+	inputDir, output string,
+	withSosa, withConsanguinity bool,
+	root int32,
+	subtree tree.SubtreeOptions,
+) error {
+	if err := checkInputDir(inputDir); err != nil {
+		return err
 	}
 
 	g, err := geneanet.New(inputDir)
@@ -51,6 +117,18 @@ func (c *GedcomCmd) Run(inputDir string) error {
 		return fmt.Errorf("failed to initialize Geneanet: %w", err)
 	}
 
+	g.SetOutput(output)
+	g.SetSosa(withSosa)
+	g.SetConsanguinity(withConsanguinity)
+
+	if root != noSubtree {
+		if !subtree.Ancestors && !subtree.Descendants {
+			subtree.Ancestors, subtree.Descendants = true, true
+		}
+
+		g.SetSubtree(root, subtree)
+	}
+
 	if err = g.Parse(); err != nil {
 		return fmt.Errorf("failed to parse Geneanet: %w", err)
 	}
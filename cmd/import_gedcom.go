@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/trois-six/geneparse/pkg/geneanet/impgedcom"
+	"github.com/trois-six/geneparse/pkg/geneanet/utils"
+	"github.com/spf13/cobra"
+)
+
+type ImportGedcomCmd struct{}
+
+func (c *ImportGedcomCmd) Command() *cobra.Command {
+	var (
+		inputFile string
+		outputDir string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "import-gedcom",
+		Short: "parse a gedcom file and create Geneanet bases",
+		Long: `The import-gedcom command will parse a gedcom file and will create the corresponding ` +
+			`Geneanet bases, in the same format as the ones downloaded by the dlextr command.`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			i, err := cmd.Flags().GetString("input")
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			o, err := cmd.Flags().GetString("outputdir")
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			return c.Run(i, o)
+		},
+	}
+
+	cmd.Flags().StringVarP(&inputFile, "input", "i", "", "Input gedcom file (required)")
+	cmd.Flags().StringVarP(&outputDir, "outputdir", "o", "output", "Output directory for Geneanet bases")
+
+	if err := cmd.MarkFlagRequired("input"); err != nil {
+		return nil
+	}
+
+	return cmd
+}
+
+func (c *ImportGedcomCmd) Run(inputFile, outputDir string) error {
+	if !utils.FileExists(inputFile) {
+		return fmt.Errorf("%w: %s", utils.ErrFileMissing, inputFile)
+	}
+
+	info, err := os.Stat(outputDir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return fmt.Errorf("could not access filesystem: %w", err)
+		}
+
+		if err = os.MkdirAll(outputDir, os.ModePerm|os.ModeDir); err != nil {
+			return fmt.Errorf("could not create output directory: %w", err)
+		}
+	} else if !info.IsDir() {
+		return fmt.Errorf("%w: %s", utils.ErrDirMustBeADir, outputDir)
+	}
+
+	imp := impgedcom.New(outputDir)
+
+	if err = imp.Read(inputFile); err != nil {
+		return fmt.Errorf("failed to parse gedcom: %w", err)
+	}
+
+	if err = imp.Write(); err != nil {
+		return fmt.Errorf("failed to write Geneanet bases: %w", err)
+	}
+
+	return nil
+}
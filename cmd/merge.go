@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/trois-six/geneparse/pkg/geneanet/duplicate"
+	"github.com/trois-six/geneparse/pkg/geneanet/gengedcom"
+	"github.com/trois-six/geneparse/pkg/geneanet/merge"
+	"github.com/trois-six/geneparse/pkg/geneanet/utils"
+	"github.com/spf13/cobra"
+)
+
+const defaultMergeOutput = "output/merged.ged"
+
+type MergeCmd struct{}
+
+func (c *MergeCmd) Command() *cobra.Command {
+	var (
+		output     string
+		links      string
+		duplicates bool
+		threshold  float64
+		maxYearGap int32
+	)
+
+	cmd := &cobra.Command{
+		Use:   "merge <dir> <dir>...",
+		Short: "merge Geneanet bases into a single gedcom file",
+		Long: `The merge command will parse several Geneanet bases downloaded by the dlextr command ` +
+			`and will create a single gedcom file with all their persons and families, renumbered ` +
+			`so that they do not collide. The persons which are the same can be linked in a mapping ` +
+			`file, whose lines hold two persons as <base>:<index>, the bases being numbered from 1 ` +
+			`in the order of the arguments, or found as duplicates of each other.`,
+		Args: cobra.MinimumNArgs(2), //nolint:gomnd
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			o, err := cmd.Flags().GetString("output")
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			l, err := cmd.Flags().GetString("links")
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			d, err := cmd.Flags().GetBool("duplicates")
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			t, err := cmd.Flags().GetFloat64("threshold")
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			g, err := cmd.Flags().GetInt32("max-year-gap")
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			var opts *duplicate.Options
+			if d {
+				opts = &duplicate.Options{Threshold: t, MaxYearGap: g}
+			}
+
+			return c.Run(args, o, l, opts)
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", defaultMergeOutput, "Gedcom file to create")
+	cmd.Flags().StringVarP(&links, "links", "l", "", "Mapping file linking the persons of the bases which are the same")
+	cmd.Flags().BoolVarP(&duplicates, "duplicates", "d", false,
+		"Link the persons of different bases found as duplicates of each other")
+	cmd.Flags().Float64VarP(&threshold, "threshold", "t", duplicate.DefaultThreshold,
+		"Minimum score, between 0 and 1, of the duplicates linked with --duplicates")
+	cmd.Flags().Int32VarP(&maxYearGap, "max-year-gap", "g", duplicate.DefaultMaxYearGap,
+		"Maximum difference between the birth or death years of two duplicates")
+
+	return cmd
+}
+
+func (c *MergeCmd) Run(inputDirs []string, output, links string, duplicates *duplicate.Options) error {
+	for _, inputDir := range inputDirs {
+		if err := checkInputDir(inputDir); err != nil {
+			return err
+		}
+	}
+
+	opts := merge.Options{Duplicates: duplicates}
+
+	if links != "" {
+		f, err := os.Open(links)
+		if err != nil {
+			return fmt.Errorf("could not open links file: %w", err)
+		}
+
+		defer f.Close()
+
+		if opts.Links, err = merge.ReadLinks(f); err != nil {
+			return fmt.Errorf("could not read links file: %w", err)
+		}
+	}
+
+	base, err := merge.Merge(inputDirs, opts)
+	if err != nil {
+		return fmt.Errorf("failed to merge bases: %w", err)
+	}
+
+	log.Printf("Merged %d bases: %d persons, %d families, %d persons linked",
+		len(inputDirs), len(base.Persons), len(base.Families), base.Linked)
+
+	genGedcom := gengedcom.New(output)
+
+	if err = genGedcom.Write(output, base.Persons, base.Families,
+		merge.Notes(base.PersonsNotes), merge.Notes(base.FamiliesNotes)); err != nil {
+		return fmt.Errorf("could not write gedcom: %w", err)
+	}
+
+	return nil
+}
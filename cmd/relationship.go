@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/trois-six/geneparse/pkg/geneanet/relationship"
+	"github.com/trois-six/geneparse/pkg/geneanet/tree"
+	"github.com/trois-six/geneparse/pkg/geneanet/utils"
+	"github.com/spf13/cobra"
+)
+
+type RelationshipCmd struct{}
+
+func (c *RelationshipCmd) Command() *cobra.Command {
+	var inputDir string
+
+	cmd := &cobra.Command{
+		Use:   "relationship <idA> <idB>",
+		Short: "compute the relationship between two persons",
+		Long: `The relationship command will parse Geneanet bases downloaded by the dlextr command ` +
+			`and will name, in English and in French, the relationship between the persons of indexes idA and idB.`,
+		Args: cobra.ExactArgs(2), //nolint:gomnd
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			i, err := cmd.Flags().GetString("inputdir")
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			a, err := strconv.ParseInt(args[0], utils.ConstDecBase, 32)
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			b, err := strconv.ParseInt(args[1], utils.ConstDecBase, 32)
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			return c.Run(i, int32(a), int32(b))
+		},
+	}
+
+	cmd.Flags().StringVarP(&inputDir, "inputdir", "i", "output", "Input directory for Geneanet bases")
+
+	return cmd
+}
+
+func personString(p *tree.Person) string {
+	return fmt.Sprintf("%s %s (%d)", p.GetFirstname(), p.GetLastname(), p.GetIndex())
+}
+
+func (c *RelationshipCmd) Run(inputDir string, a, b int32) error {
+	if err := checkInputDir(inputDir); err != nil {
+		return err
+	}
+
+	t, err := tree.Load(inputDir)
+	if err != nil {
+		return fmt.Errorf("failed to load tree: %w", err)
+	}
+
+	r, err := relationship.Find(t, a, b)
+	if err != nil {
+		return fmt.Errorf("failed to compute relationship: %w", err)
+	}
+
+	pa, pb := personString(r.A), personString(r.B)
+
+	switch r.Kind {
+	case relationship.None:
+		fmt.Printf("en: %s and %s are not related\n", pa, pb)
+		fmt.Printf("fr: %s et %s n'ont pas de lien de parenté\n", pa, pb)
+	case relationship.Same:
+		fmt.Printf("en: %s and %s are the same person\n", pa, pb)
+		fmt.Printf("fr: %s et %s sont la même personne\n", pa, pb)
+	default:
+		fmt.Printf("en: %s is the %s of %s\n", pa, r.English(), pb)
+		fmt.Printf("fr: %s : %s de %s\n", pa, r.French(), pb)
+	}
+
+	for _, ancestor := range r.Ancestors {
+		if ancestor != r.A && ancestor != r.B {
+			fmt.Printf("common ancestor: %s\n", personString(ancestor))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/trois-six/geneparse/pkg/geneanet/search"
+	"github.com/trois-six/geneparse/pkg/geneanet/tree"
+	"github.com/trois-six/geneparse/pkg/geneanet/utils"
+	"github.com/spf13/cobra"
+)
+
+type SearchCmd struct{}
+
+func (c *SearchCmd) Command() *cobra.Command {
+	var (
+		inputDir string
+		format   string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "search <query>",
+		Short: "search persons in Geneanet bases",
+		Long: `The search command will parse Geneanet bases downloaded by the dlextr command ` +
+			`and will list the persons matching the query, such as ` +
+			`'surname:Martin firstname:jean* born:1750..1800 died:..1850 place:"Saint Étienne"'. ` +
+			`The words without a field are looked for in the names and the places, ` +
+			`ignoring accents and case.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			i, err := cmd.Flags().GetString("inputdir")
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			f, err := cmd.Flags().GetString("format")
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			return c.Run(i, f, strings.Join(args, " "))
+		},
+	}
+
+	cmd.Flags().StringVarP(&inputDir, "inputdir", "i", "output", "Input directory for Geneanet bases")
+	cmd.Flags().StringVarP(&format, "format", "f", formatText, "Output format: text or json")
+
+	return cmd
+}
+
+func (c *SearchCmd) Run(inputDir, format, query string) error {
+	if err := checkFormat(format); err != nil {
+		return err
+	}
+
+	q, err := search.ParseQuery(query)
+	if err != nil {
+		return fmt.Errorf(utils.ErrParseInput, err)
+	}
+
+	if err = checkInputDir(inputDir); err != nil {
+		return err
+	}
+
+	t, err := tree.Load(inputDir)
+	if err != nil {
+		return fmt.Errorf("failed to load tree: %w", err)
+	}
+
+	results := search.NewIndex(t).Search(q)
+
+	if format == formatJSON {
+		return printJSON(results)
+	}
+
+	for _, r := range results {
+		fmt.Printf("%d\t%s.%d %s\t%s - %s\n", r.Index, r.Firstname, r.Occ, r.Lastname, r.Birth, r.Death)
+	}
+
+	fmt.Printf("%d person(s) found\n", len(results))
+
+	return nil
+}
@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/trois-six/geneparse/pkg/geneanet/dlextr"
+	"github.com/trois-six/geneparse/pkg/geneanet/utils"
+	"github.com/spf13/cobra"
+)
+
+var errSessionRequired = errors.New("--session is required")
+
+// defaultSessionFile returns the session file used when --session is not
+// given, none if the configuration directory of the user is unknown.
+func defaultSessionFile() string {
+	path, err := dlextr.DefaultSessionFile()
+	if err != nil {
+		return ""
+	}
+
+	return path
+}
+
+func addSessionFlag(cmd *cobra.Command, session *string) {
+	cmd.Flags().StringVar(session, "session", defaultSessionFile(),
+		"File the Geneanet session is saved to and reused from, empty to log in on every run")
+}
+
+type LoginCmd struct{}
+
+func (c *LoginCmd) Command() *cobra.Command {
+	var (
+		username     string
+		password     string
+		passwordFile string
+		config       string
+		timeout      string
+		session      string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "login",
+		Short: "log in to Geneanet and save the session",
+		Long: `The login command will log in to Geneanet as if it was the Geneanet Android app, ` +
+			`and will save the session to the session file, readable by the user only, so that the ` +
+			`other commands reuse it until it expires without needing the password. ` +
+			`The username and the password are read from the flags, the ` + envUsername + ` and ` +
+			envPassword + ` environment variables, the password file or the config file, ` +
+			`and are asked when missing.`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			u, err := cmd.Flags().GetString("username")
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			p, err := cmd.Flags().GetString("password")
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			pf, err := cmd.Flags().GetString("password-file")
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			cf, err := cmd.Flags().GetString("config")
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			if u, p, err = resolveCredentials(u, p, pf, cf); err != nil {
+				return err
+			}
+
+			ts, err := cmd.Flags().GetString("timeout")
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			t, err := time.ParseDuration(ts)
+			if err != nil {
+				return fmt.Errorf("could not parse timeout: %w", err)
+			}
+
+			s, err := cmd.Flags().GetString("session")
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			return c.Run(u, p, t, s)
+		},
+	}
+
+	addCredentialsFlags(cmd, &username, &password, &passwordFile, &config)
+	cmd.Flags().StringVarP(&timeout, "timeout", "t", loginTimeout, "Connection timeout for requests to Geneanet")
+	addSessionFlag(cmd, &session)
+
+	return cmd
+}
+
+func (c *LoginCmd) Run(username, password string, timeout time.Duration, session string) error {
+	if session == "" {
+		return errSessionRequired
+	}
+
+	if username == "" || password == "" {
+		var err error
+
+		if username, password, err = promptCredentials(username); err != nil {
+			return err
+		}
+
+		if username == "" || password == "" {
+			return utils.ErrNoCredentials
+		}
+	}
+
+	d := dlextr.New(username, password, "", timeout)
+	d.SetSessionFile(session)
+
+	if err := d.Login(); err != nil {
+		return fmt.Errorf("failed to log in: %w", err)
+	}
+
+	if err := d.SaveSession(); err != nil {
+		return fmt.Errorf("failed to save session: %w", err)
+	}
+
+	fmt.Printf("Logged in as %s, session saved to %s\n", username, session)
+
+	return nil
+}
+
+type ForgetCmd struct{}
+
+func (c *ForgetCmd) Command() *cobra.Command {
+	var session string
+
+	cmd := &cobra.Command{
+		Use:   "forget",
+		Short: "forget the saved Geneanet session",
+		Long: `The forget command will remove the session file saved by the login command, ` +
+			`so that the other commands need the username and the password again. The session ` +
+			`is not ended on Geneanet, which has no known logout request for the app: it stays ` +
+			`valid there until it expires.`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			s, err := cmd.Flags().GetString("session")
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			return c.Run(s)
+		},
+	}
+
+	addSessionFlag(cmd, &session)
+
+	return cmd
+}
+
+func (c *ForgetCmd) Run(session string) error {
+	if session == "" {
+		return errSessionRequired
+	}
+
+	removed, err := dlextr.RemoveSession(session)
+	if err != nil {
+		return err
+	}
+
+	if removed {
+		fmt.Printf("Session removed from %s\n", session)
+	} else {
+		fmt.Printf("No session saved in %s\n", session)
+	}
+
+	return nil
+}
@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/trois-six/geneparse/pkg/geneanet/snapshot"
+	"github.com/trois-six/geneparse/pkg/geneanet/utils"
+	"github.com/spf13/cobra"
+)
+
+var errTreeRequired = errors.New("--tree is required")
+
+type SnapshotsCmd struct{}
+
+func (c *SnapshotsCmd) Command() *cobra.Command {
+	var (
+		inputDir  string
+		tree      string
+		format    string
+		setLatest int64
+		keep      int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "snapshots",
+		Short: "list and manage the snapshots of Geneanet bases",
+		Long: `The snapshots command will list the snapshots of the trees stored by the dlextr command ` +
+			`with --snapshots. It can also point the ` + snapshot.Latest + ` snapshot of a tree to ` +
+			`a previous one, to roll back, and remove the oldest snapshots of the trees.`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			i, err := cmd.Flags().GetString("inputdir")
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			t, err := cmd.Flags().GetString("tree")
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			f, err := cmd.Flags().GetString("format")
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			l, err := cmd.Flags().GetInt64("set-latest")
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			k, err := cmd.Flags().GetInt("keep")
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			return c.Run(i, t, f, l, k)
+		},
+	}
+
+	cmd.Flags().StringVarP(&inputDir, "inputdir", "i", "output", "Input directory for the snapshots of Geneanet bases")
+	cmd.Flags().StringVar(&tree, "tree", "", "Id of the tree whose snapshots are listed, all the trees by default")
+	cmd.Flags().StringVarP(&format, "format", "f", formatText, "Output format: text or json")
+	cmd.Flags().Int64Var(&setLatest, "set-latest", 0, "Timestamp of the snapshot of the tree to make the latest one")
+	cmd.Flags().IntVar(&keep, "keep", 0, "Number of snapshots to keep for each tree, removing the oldest ones")
+
+	return cmd
+}
+
+func printSnapshots(snapshots []snapshot.Snapshot) {
+	for _, s := range snapshots {
+		latest := ""
+		if s.Latest {
+			latest = " (" + snapshot.Latest + ")"
+		}
+
+		fmt.Printf("%-20s %d  %s  %7d persons  %s%s\n",
+			s.Tree, s.Timestamp, s.Date.Format("2006-01-02 15:04:05"), s.Persons, s.Dir, latest)
+	}
+}
+
+func (c *SnapshotsCmd) Run(inputDir, tree, format string, setLatest int64, keep int) error {
+	if err := checkFormat(format); err != nil {
+		return err
+	}
+
+	if err := checkInputDir(inputDir); err != nil {
+		return err
+	}
+
+	store := snapshot.New(inputDir)
+
+	if setLatest != 0 {
+		if tree == "" {
+			return fmt.Errorf("%w with --set-latest", errTreeRequired)
+		}
+
+		if err := store.SetLatest(tree, setLatest); err != nil {
+			return fmt.Errorf("failed to set the latest snapshot: %w", err)
+		}
+	}
+
+	trees := []string{tree}
+
+	if tree == "" {
+		var err error
+
+		if trees, err = store.Trees(); err != nil {
+			return fmt.Errorf("failed to list the trees: %w", err)
+		}
+	}
+
+	snapshots := []snapshot.Snapshot{}
+
+	for _, t := range trees {
+		if _, err := store.Prune(t, keep); err != nil {
+			return fmt.Errorf("failed to prune the snapshots of %s: %w", t, err)
+		}
+
+		s, err := store.List(t)
+		if err != nil {
+			return fmt.Errorf("failed to list the snapshots of %s: %w", t, err)
+		}
+
+		snapshots = append(snapshots, s...)
+	}
+
+	if format == formatJSON {
+		return printJSON(snapshots)
+	}
+
+	printSnapshots(snapshots)
+
+	return nil
+}
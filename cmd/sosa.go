@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/trois-six/geneparse/pkg/geneanet/sosa"
+	"github.com/trois-six/geneparse/pkg/geneanet/tree"
+	"github.com/trois-six/geneparse/pkg/geneanet/utils"
+	"github.com/spf13/cobra"
+)
+
+const rootFromBase = -1
+
+type SosaCmd struct{}
+
+func (c *SosaCmd) Command() *cobra.Command {
+	var (
+		inputDir    string
+		root        int32
+		generations int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "sosa",
+		Short: "compute the Sosa numbers of the ancestors of a person",
+		Long: `The sosa command will parse Geneanet bases downloaded by the dlextr command ` +
+			`and will list the ancestors of the root person of the base, or of the given person, ` +
+			`with their Sosa (Ahnentafel) numbers.`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			i, err := cmd.Flags().GetString("inputdir")
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			r, err := cmd.Flags().GetInt32("root")
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			g, err := cmd.Flags().GetInt("generations")
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			return c.Run(i, r, g)
+		},
+	}
+
+	cmd.Flags().StringVarP(&inputDir, "inputdir", "i", "output", "Input directory for Geneanet bases")
+	cmd.Flags().Int32VarP(&root, "root", "r", rootFromBase, "Index of the root person (default: root person of the base)")
+	cmd.Flags().IntVarP(&generations, "generations", "g", 0, "Maximum number of generations (default: all, up to "+strconv.Itoa(sosa.MaxGenerations)+")")
+
+	return cmd
+}
+
+type sosaEntry struct {
+	number string
+	person *tree.Person
+}
+
+func (c *SosaCmd) Run(inputDir string, root int32, generations int) error {
+	if err := checkInputDir(inputDir); err != nil {
+		return err
+	}
+
+	t, err := tree.Load(inputDir)
+	if err != nil {
+		return fmt.Errorf("failed to load tree: %w", err)
+	}
+
+	if root == rootFromBase {
+		root = sosa.Root(t)
+	}
+
+	numbers, err := sosa.Compute(t, root, generations)
+	if err != nil {
+		return fmt.Errorf("failed to compute sosa numbers: %w", err)
+	}
+
+	var entries []sosaEntry
+
+	for index, personNumbers := range numbers {
+		for _, number := range personNumbers {
+			entries = append(entries, sosaEntry{number: number.String(), person: t.Person(index)})
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if len(entries[i].number) != len(entries[j].number) {
+			return len(entries[i].number) < len(entries[j].number)
+		}
+
+		return entries[i].number < entries[j].number
+	})
+
+	for _, e := range entries {
+		fmt.Printf("%s\t%d\t%s %s\n", e.number, e.person.GetIndex(), e.person.GetFirstname(), e.person.GetLastname())
+	}
+
+	return nil
+}
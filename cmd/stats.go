@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/trois-six/geneparse/pkg/geneanet/stats"
+	"github.com/trois-six/geneparse/pkg/geneanet/tree"
+	"github.com/trois-six/geneparse/pkg/geneanet/utils"
+	"github.com/spf13/cobra"
+)
+
+type StatsCmd struct{}
+
+func (c *StatsCmd) Command() *cobra.Command {
+	var (
+		inputDir string
+		format   string
+		top      int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "stats",
+		Short: "report statistics about Geneanet bases",
+		Long: `The stats command will parse Geneanet bases downloaded by the dlextr command ` +
+			`and will report counts of persons, families and events, the coverage of the dates, ` +
+			`the most common names and places, the generation depth and the average lifespans.`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			i, err := cmd.Flags().GetString("inputdir")
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			f, err := cmd.Flags().GetString("format")
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			t, err := cmd.Flags().GetInt("top")
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			return c.Run(i, f, t)
+		},
+	}
+
+	cmd.Flags().StringVarP(&inputDir, "inputdir", "i", "output", "Input directory for Geneanet bases")
+	cmd.Flags().StringVarP(&format, "format", "f", formatText, "Output format: text or json")
+	cmd.Flags().IntVarP(&top, "top", "t", stats.DefaultTop, "Number of most common names and places to report")
+
+	return cmd
+}
+
+func printCounts(title string, counts map[string]int) {
+	names := make([]string, 0, len(counts))
+
+	for name := range counts {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	fmt.Printf("%s:\n", title)
+
+	for _, name := range names {
+		fmt.Printf("  %-28s %d\n", name, counts[name])
+	}
+}
+
+func printTop(title string, counts []stats.Count) {
+	fmt.Printf("%s:\n", title)
+
+	for _, count := range counts {
+		fmt.Printf("  %-28s %d\n", count.Name, count.Count)
+	}
+}
+
+func printStats(s *stats.Stats) {
+	fmt.Printf("persons: %d\nfamilies: %d\n", s.Persons, s.Families)
+
+	printCounts("persons by sex", s.BySex)
+	printCounts("persons by death type", s.ByDeathType)
+	printCounts("families by marriage type", s.ByMarriageType)
+	printCounts("events", s.Events)
+
+	names := make([]string, 0, len(s.DateCoverage))
+
+	for name := range s.DateCoverage {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	fmt.Println("date coverage (dated / precise / total):")
+
+	for _, name := range names {
+		coverage := s.DateCoverage[name]
+		fmt.Printf("  %-28s %d / %d / %d\n", name, coverage.Dated, coverage.Precise, coverage.Total)
+	}
+
+	printTop("most common surnames", s.Surnames)
+	printTop("most common first names", s.Firstnames)
+	printTop("most common places", s.Places)
+
+	fmt.Printf("generation depth from the Sosa root (%d): %d, with %d ancestors\n",
+		s.SosaRoot, s.GenerationDepth, s.Ancestors)
+
+	fmt.Println("average lifespan by century of birth:")
+
+	for _, lifespan := range s.Lifespans {
+		fmt.Printf("  %-28d %.1f years (%d persons)\n", lifespan.Century, lifespan.Average, lifespan.Persons)
+	}
+}
+
+func (c *StatsCmd) Run(inputDir, format string, top int) error {
+	if err := checkFormat(format); err != nil {
+		return err
+	}
+
+	if err := checkInputDir(inputDir); err != nil {
+		return err
+	}
+
+	t, err := tree.Load(inputDir)
+	if err != nil {
+		return fmt.Errorf("failed to load tree: %w", err)
+	}
+
+	s := stats.Compute(t, top)
+
+	if format == formatJSON {
+		return printJSON(s)
+	}
+
+	printStats(s)
+
+	return nil
+}
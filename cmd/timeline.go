@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/trois-six/geneparse/pkg/geneanet/timeline"
+	"github.com/trois-six/geneparse/pkg/geneanet/tree"
+	"github.com/trois-six/geneparse/pkg/geneanet/utils"
+	"github.com/spf13/cobra"
+)
+
+type TimelineCmd struct{}
+
+func (c *TimelineCmd) Command() *cobra.Command {
+	var (
+		inputDir string
+		format   string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "timeline <id>",
+		Short: "list the events of the life of a person",
+		Long: `The timeline command will parse Geneanet bases downloaded by the dlextr command ` +
+			`and will list, in chronological order, the events of the person of index id, ` +
+			`the events of its families and the events it was a witness of.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			i, err := cmd.Flags().GetString("inputdir")
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			f, err := cmd.Flags().GetString("format")
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			p, err := strconv.ParseInt(args[0], utils.ConstDecBase, 32)
+			if err != nil {
+				return fmt.Errorf(utils.ErrParseInput, err)
+			}
+
+			return c.Run(i, f, int32(p))
+		},
+	}
+
+	cmd.Flags().StringVarP(&inputDir, "inputdir", "i", "output", "Input directory for Geneanet bases")
+	cmd.Flags().StringVarP(&format, "format", "f", formatText, "Output format: text or json")
+
+	return cmd
+}
+
+func (c *TimelineCmd) Run(inputDir, format string, person int32) error {
+	if err := checkFormat(format); err != nil {
+		return err
+	}
+
+	if err := checkInputDir(inputDir); err != nil {
+		return err
+	}
+
+	t, err := tree.Load(inputDir)
+	if err != nil {
+		return fmt.Errorf("failed to load tree: %w", err)
+	}
+
+	entries, err := timeline.Timeline(t, person)
+	if err != nil {
+		return fmt.Errorf("failed to build timeline: %w", err)
+	}
+
+	if format == formatJSON {
+		if entries == nil {
+			entries = []timeline.Entry{}
+		}
+
+		return printJSON(entries)
+	}
+
+	fmt.Printf("timeline of %s\n", personString(t.Person(person)))
+
+	for _, e := range entries {
+		fmt.Printf("%-20s %-10s %s", e.Date, e.Role, e.Event)
+
+		if e.Person != nil {
+			fmt.Printf(" of %s", personString(t.Person(*e.Person)))
+		}
+
+		if e.Place != "" {
+			fmt.Printf(", %s", e.Place)
+		}
+
+		fmt.Println()
+	}
+
+	return nil
+}
@@ -5,6 +5,8 @@ go 1.17
 require (
 	github.com/elliotchance/gedcom v38.0.0+incompatible
 	github.com/spf13/cobra v1.3.0
+	golang.org/x/term v0.1.0
+	golang.org/x/text v0.3.7
 	google.golang.org/protobuf v1.27.1
 )
 
@@ -12,5 +14,5 @@ require (
 	github.com/elliotchance/tf v1.7.0 // indirect
 	github.com/inconshreveable/mousetrap v1.0.0 // indirect
 	github.com/spf13/pflag v1.0.5 // indirect
-	golang.org/x/text v0.3.7 // indirect
+	golang.org/x/sys v0.0.0-20211205182925-97ca703d548d // indirect
 )
@@ -18,8 +18,24 @@ func main() {
 		},
 	}
 
+	rootCmd.AddCommand((&cmd.AccountCmd{}).Command())
+	rootCmd.AddCommand((&cmd.AnniversariesCmd{}).Command())
+	rootCmd.AddCommand((&cmd.CheckCmd{}).Command())
+	rootCmd.AddCommand((&cmd.ConsanguinityCmd{}).Command())
+	rootCmd.AddCommand((&cmd.DiffCmd{}).Command())
 	rootCmd.AddCommand((&cmd.DownloadAndExtractCmd{}).Command())
+	rootCmd.AddCommand((&cmd.DuplicatesCmd{}).Command())
+	rootCmd.AddCommand((&cmd.ForgetCmd{}).Command())
 	rootCmd.AddCommand((&cmd.GedcomCmd{}).Command())
+	rootCmd.AddCommand((&cmd.ImportGedcomCmd{}).Command())
+	rootCmd.AddCommand((&cmd.LoginCmd{}).Command())
+	rootCmd.AddCommand((&cmd.MergeCmd{}).Command())
+	rootCmd.AddCommand((&cmd.RelationshipCmd{}).Command())
+	rootCmd.AddCommand((&cmd.SearchCmd{}).Command())
+	rootCmd.AddCommand((&cmd.SnapshotsCmd{}).Command())
+	rootCmd.AddCommand((&cmd.SosaCmd{}).Command())
+	rootCmd.AddCommand((&cmd.StatsCmd{}).Command())
+	rootCmd.AddCommand((&cmd.TimelineCmd{}).Command())
 
 	if err := rootCmd.Execute(); err != nil {
 		os.Exit(1)
@@ -0,0 +1,197 @@
+package check
+
+import (
+	"fmt"
+
+	"github.com/trois-six/geneparse/pkg/geneanet/api"
+	"github.com/trois-six/geneparse/pkg/geneanet/utils"
+)
+
+type Kind string
+
+const (
+	KindBirthAfterDeath       Kind = "birth-after-death"
+	KindParentTooYoung        Kind = "parent-too-young"
+	KindBirthBeforeParent     Kind = "birth-before-parent"
+	KindMarriageBeforeBirth   Kind = "marriage-before-birth"
+	KindBirthAfterMotherDeath Kind = "birth-after-mother-death"
+	KindDanglingIndex         Kind = "dangling-index"
+	KindInconsistentLink      Kind = "inconsistent-link"
+
+	// minParentAge is the age under which a parent is deemed too young.
+	minParentAge = 12
+)
+
+// Issue is an inconsistency found in a base, about a person, a family or both.
+type Issue struct {
+	Kind    Kind   `json:"kind"`
+	Person  *int32 `json:"person,omitempty"`
+	Family  *int32 `json:"family,omitempty"`
+	Message string `json:"message"`
+}
+
+type checker struct {
+	persons  []*api.Person
+	families []*api.Family
+	issues   []Issue
+}
+
+func name(p *api.Person) string {
+	return fmt.Sprintf("%s %s (%d)", p.GetFirstname(), p.GetLastname(), p.GetIndex())
+}
+
+func (c *checker) add(kind Kind, person, family *int32, format string, a ...interface{}) {
+	c.issues = append(c.issues, Issue{
+		Kind:    kind,
+		Person:  person,
+		Family:  family,
+		Message: fmt.Sprintf(format, a...),
+	})
+}
+
+func (c *checker) person(i int32) *api.Person {
+	if i < 0 || int(i) >= len(c.persons) {
+		return nil
+	}
+
+	return c.persons[i]
+}
+
+func (c *checker) family(i int32) *api.Family {
+	if i < 0 || int(i) >= len(c.families) {
+		return nil
+	}
+
+	return c.families[i]
+}
+
+func contains(list []int32, v int32) bool {
+	for _, e := range list {
+		if e == v {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *checker) checkPerson(p *api.Person) {
+	index := p.Index
+
+	if cmp, ok := utils.CompareDates(p.GetBirthDate(), p.GetDeathDate()); ok && cmp > 0 {
+		c.add(KindBirthAfterDeath, index, nil, "%s was born after their death", name(p))
+	}
+
+	if p.Parents != nil {
+		switch family := c.family(p.GetParents()); {
+		case family == nil:
+			c.add(KindDanglingIndex, index, nil, "%s has parents family %d which does not exist", name(p), p.GetParents())
+		case !contains(family.GetChildren(), p.GetIndex()):
+			c.add(KindInconsistentLink, index, p.Parents,
+				"%s has parents family %d which does not list them as a child", name(p), p.GetParents())
+		}
+	}
+
+	for _, f := range p.GetFamilies() {
+		f := f
+
+		switch family := c.family(f); {
+		case family == nil:
+			c.add(KindDanglingIndex, index, nil, "%s has family %d which does not exist", name(p), f)
+		case family.GetFather() != p.GetIndex() && family.GetMother() != p.GetIndex():
+			c.add(KindInconsistentLink, index, &f,
+				"%s has family %d which does not list them as father nor mother", name(p), f)
+		}
+	}
+}
+
+func (c *checker) checkSpouse(f *api.Family, role string, spouse int32) *api.Person {
+	p := c.person(spouse)
+	if p == nil {
+		c.add(KindDanglingIndex, nil, f.Index, "family %d has %s %d who does not exist", f.GetIndex(), role, spouse)
+
+		return nil
+	}
+
+	if !contains(p.GetFamilies(), f.GetIndex()) {
+		c.add(KindInconsistentLink, p.Index, f.Index,
+			"family %d has %s %s who does not list it as a family", f.GetIndex(), role, name(p))
+	}
+
+	if cmp, ok := utils.CompareDates(f.GetMarriageDate(), p.GetBirthDate()); ok && cmp < 0 {
+		c.add(KindMarriageBeforeBirth, p.Index, f.Index,
+			"family %d has a marriage before the birth of %s %s", f.GetIndex(), role, name(p))
+	}
+
+	return p
+}
+
+func (c *checker) checkChild(f *api.Family, child *api.Person, parents map[string]*api.Person) {
+	childYear, childOk := utils.DateYear(child.GetBirthDate())
+
+	for _, role := range []string{"father", "mother"} {
+		parent := parents[role]
+		if parent == nil {
+			continue
+		}
+
+		// A child born before their parent is not a parent being too young.
+		if cmp, ok := utils.CompareDates(child.GetBirthDate(), parent.GetBirthDate()); ok && cmp < 0 {
+			c.add(KindBirthBeforeParent, child.Index, f.Index,
+				"%s was born before their %s %s", name(child), role, name(parent))
+
+			continue
+		}
+
+		if parentYear, ok := utils.DateYear(parent.GetBirthDate()); ok && childOk && childYear-parentYear < minParentAge {
+			c.add(KindParentTooYoung, child.Index, f.Index,
+				"%s was born when their %s %s was %d", name(child), role, name(parent), childYear-parentYear)
+		}
+	}
+
+	if mother := parents["mother"]; mother != nil {
+		if cmp, ok := utils.CompareDates(child.GetBirthDate(), mother.GetDeathDate()); ok && cmp > 0 {
+			c.add(KindBirthAfterMotherDeath, child.Index, f.Index,
+				"%s was born after the death of their mother %s", name(child), name(mother))
+		}
+	}
+}
+
+func (c *checker) checkFamily(f *api.Family) {
+	parents := map[string]*api.Person{
+		"father": c.checkSpouse(f, "father", f.GetFather()),
+		"mother": c.checkSpouse(f, "mother", f.GetMother()),
+	}
+
+	for _, i := range f.GetChildren() {
+		child := c.person(i)
+		if child == nil {
+			c.add(KindDanglingIndex, nil, f.Index, "family %d has child %d who does not exist", f.GetIndex(), i)
+
+			continue
+		}
+
+		if child.Parents == nil || child.GetParents() != f.GetIndex() {
+			c.add(KindInconsistentLink, child.Index, f.Index,
+				"family %d has child %s who does not have it as parents family", f.GetIndex(), name(child))
+		}
+
+		c.checkChild(f, child, parents)
+	}
+}
+
+// Check walks persons and families, and reports the dates which are not
+// consistent with each other and the links which are broken.
+func Check(persons []*api.Person, families []*api.Family) []Issue {
+	c := &checker{persons: persons, families: families}
+
+	for _, p := range persons {
+		c.checkPerson(p)
+	}
+
+	for _, f := range families {
+		c.checkFamily(f)
+	}
+
+	return c.issues
+}
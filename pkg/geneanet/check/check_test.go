@@ -0,0 +1,96 @@
+package check
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/trois-six/geneparse/pkg/geneanet/api"
+	"github.com/trois-six/geneparse/pkg/geneanet/tree/treetest"
+	"google.golang.org/protobuf/proto"
+)
+
+// family holds a couple born in 1850 and 1852, married in 1875, and their
+// child born in 1880, to be altered by the test cases.
+type family struct {
+	b                     *treetest.Builder
+	father, mother, child *api.Person
+	f                     *api.Family
+}
+
+func newFamily() *family {
+	b := &treetest.Builder{}
+	father := b.Person(api.Sex_MALE, "Pierre", "Martin")
+	father.BirthDate = treetest.Date(1850, 1, 1)
+	mother := b.Person(api.Sex_FEMALE, "Marie", "Durand")
+	mother.BirthDate = treetest.Date(1852, 1, 1)
+	child := b.Person(api.Sex_MALE, "Jean", "Martin")
+	child.BirthDate = treetest.Date(1880, 1, 1)
+	f := b.Family(father, mother, child)
+	f.MarriageDate = treetest.Date(1875, 1, 1)
+
+	return &family{b: b, father: father, mother: mother, child: child, f: f}
+}
+
+func TestCheck(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		alter func(f *family)
+		want  []Kind
+	}{
+		{
+			name:  "consistent",
+			alter: func(f *family) {},
+		},
+		{
+			name:  "birth after death",
+			alter: func(f *family) { treetest.Died(f.father, treetest.Date(1849, 1, 1)) },
+			want:  []Kind{KindBirthAfterDeath},
+		},
+		{
+			name:  "parent too young",
+			alter: func(f *family) { f.mother.BirthDate = treetest.Date(1870, 1, 1) },
+			want:  []Kind{KindParentTooYoung},
+		},
+		{
+			name:  "birth before parent",
+			alter: func(f *family) { f.child.BirthDate = treetest.Date(1851, 1, 1) },
+			want:  []Kind{KindParentTooYoung, KindBirthBeforeParent},
+		},
+		{
+			name:  "birth after the death of the mother",
+			alter: func(f *family) { treetest.Died(f.mother, treetest.Date(1879, 1, 1)) },
+			want:  []Kind{KindBirthAfterMotherDeath},
+		},
+		{
+			name:  "birth the day the mother died",
+			alter: func(f *family) { treetest.Died(f.mother, treetest.Date(1880, 1, 1)) },
+		},
+		{
+			name:  "dangling parents",
+			alter: func(f *family) { f.child.Parents = proto.Int32(3) },
+			want:  []Kind{KindDanglingIndex, KindInconsistentLink},
+		},
+		{
+			name:  "child not listed",
+			alter: func(f *family) { f.f.Children = nil },
+			want:  []Kind{KindInconsistentLink},
+		},
+		{
+			name:  "spouse not listing the family",
+			alter: func(f *family) { f.father.Families = nil },
+			want:  []Kind{KindInconsistentLink},
+		},
+	} {
+		f := newFamily()
+		tc.alter(f)
+
+		var got []Kind
+		for _, issue := range Check(f.b.Persons(), f.b.Families()) {
+			got = append(got, issue.Kind)
+		}
+
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: Check() = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
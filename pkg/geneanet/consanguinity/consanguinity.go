@@ -0,0 +1,193 @@
+package consanguinity
+
+import (
+	"fmt"
+
+	"github.com/trois-six/geneparse/pkg/geneanet/tree"
+	"github.com/trois-six/geneparse/pkg/geneanet/utils"
+)
+
+// unknown is the number of the missing parents in the renumbered pedigree.
+const unknown = 0
+
+// Coefficients holds Wright's inbreeding coefficient of each person, indexed
+// by person index.
+type Coefficients []float64
+
+// pedigree renumbers the persons from 1 so that parents come before their
+// children, as required by the algorithm of Meuwissen and Luo.
+type pedigree struct {
+	// persons maps the numbers to the person indexes
+	persons []int32
+	// numbers maps the person indexes to the numbers
+	numbers []int
+	sire    []int
+	dam     []int
+	// depth is the length of the longest line from the person up to a founder,
+	// which is greater than the depths of all its ancestors
+	depth []int
+}
+
+func (p *pedigree) parent(person *tree.Person) int {
+	if person == nil {
+		return unknown
+	}
+
+	return p.numbers[person.GetIndex()]
+}
+
+// number numbers the ancestors of person, then person itself. The walk is
+// iterative as lines may be hundreds of generations long; expanded marks the
+// persons whose parents are being numbered, to detect loops.
+func (p *pedigree) number(person *tree.Person, expanded []bool) error {
+	stack := []*tree.Person{person}
+
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+		index := current.GetIndex()
+
+		switch {
+		case p.numbers[index] != unknown:
+			stack = stack[:len(stack)-1]
+		case expanded[index]:
+			stack = stack[:len(stack)-1]
+			expanded[index] = false
+
+			p.persons = append(p.persons, index)
+			p.numbers[index] = len(p.persons) - 1
+			sire, dam := p.parent(current.Father()), p.parent(current.Mother())
+			depth := 0
+
+			for _, parent := range []int{sire, dam} {
+				if parent != unknown && p.depth[parent] >= depth {
+					depth = p.depth[parent] + 1
+				}
+			}
+
+			p.sire = append(p.sire, sire)
+			p.dam = append(p.dam, dam)
+			p.depth = append(p.depth, depth)
+		default:
+			expanded[index] = true
+
+			for _, parent := range []*tree.Person{current.Father(), current.Mother()} {
+				if parent == nil || p.numbers[parent.GetIndex()] != unknown {
+					continue
+				}
+
+				if expanded[parent.GetIndex()] {
+					return fmt.Errorf("%w: person %d", utils.ErrAncestryLoop, parent.GetIndex())
+				}
+
+				stack = append(stack, parent)
+			}
+		}
+	}
+
+	return nil
+}
+
+func newPedigree(t *tree.Tree) (*pedigree, error) {
+	n := len(t.Persons())
+	p := &pedigree{
+		persons: make([]int32, 1, n+1),
+		numbers: make([]int, n),
+		sire:    make([]int, 1, n+1),
+		dam:     make([]int, 1, n+1),
+		depth:   make([]int, 1, n+1),
+	}
+	expanded := make([]bool, n)
+
+	for _, person := range t.Persons() {
+		if err := p.number(person, expanded); err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+// Compute returns the inbreeding coefficient of every person of the tree, with
+// the algorithm of Meuwissen and Luo (1992), which only walks the ancestors of
+// each couple once and so scales to large bases. Persons sharing the same
+// parents share the same coefficient, which is only computed once.
+//
+// The ancestors are walked by decreasing depth rather than in a sorted list,
+// which is enough for each one to be reached after all its descendants.
+func Compute(t *tree.Tree) (Coefficients, error) {
+	p, err := newPedigree(t)
+	if err != nil {
+		return nil, err
+	}
+
+	n := len(p.persons)
+	f := make([]float64, n)
+	// d is the variance of the Mendelian sampling of each person
+	d := make([]float64, n)
+	// l is the contribution of each ancestor to the current person
+	l := make([]float64, n)
+	// pending holds the ancestors of the current person left to walk, by depth
+	pending := make([][]int, 0)
+	couples := make(map[[2]int]float64)
+
+	f[unknown] = -1
+
+	for i := 1; i < n; i++ {
+		s, dam := p.sire[i], p.dam[i]
+		d[i] = 0.5 - 0.25*(f[s]+f[dam]) //nolint:gomnd
+
+		if s == unknown || dam == unknown {
+			continue
+		}
+
+		if fi, ok := couples[[2]int{s, dam}]; ok {
+			f[i] = fi
+
+			continue
+		}
+
+		fi := -1.0
+		l[i] = 1
+
+		for len(pending) <= p.depth[i] {
+			pending = append(pending, nil)
+		}
+
+		pending[p.depth[i]] = append(pending[p.depth[i]], i)
+
+		for depth := p.depth[i]; depth >= 0; depth-- {
+			for _, j := range pending[depth] {
+				r := 0.5 * l[j] //nolint:gomnd
+
+				for _, a := range []int{p.sire[j], p.dam[j]} {
+					if a == unknown {
+						continue
+					}
+
+					// a contribution of 0 means that a is not pending yet
+					if l[a] == 0 {
+						pending[p.depth[a]] = append(pending[p.depth[a]], a)
+					}
+
+					l[a] += r
+				}
+
+				fi += l[j] * l[j] * d[j]
+				l[j] = 0
+			}
+
+			pending[depth] = pending[depth][:0]
+		}
+
+		f[i] = fi
+		couples[[2]int{s, dam}] = fi
+	}
+
+	coefficients := make(Coefficients, len(t.Persons()))
+
+	for i := 1; i < n; i++ {
+		coefficients[p.persons[i]] = f[i]
+	}
+
+	return coefficients, nil
+}
@@ -0,0 +1,159 @@
+package consanguinity
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/trois-six/geneparse/pkg/geneanet/api"
+	"github.com/trois-six/geneparse/pkg/geneanet/tree/treetest"
+	"github.com/trois-six/geneparse/pkg/geneanet/utils"
+)
+
+const epsilon = 1e-12
+
+// couple adds a man and a woman and their family, returning the parents.
+func couple(b *treetest.Builder, children ...*api.Person) (*api.Person, *api.Person) {
+	father := b.Person(api.Sex_MALE, "Father", "X")
+	mother := b.Person(api.Sex_FEMALE, "Mother", "X")
+	b.Family(father, mother, children...)
+
+	return father, mother
+}
+
+func TestCompute(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		// build returns the person whose coefficient is checked
+		build func(b *treetest.Builder) *api.Person
+		want  float64
+	}{
+		{
+			name: "unrelated parents",
+			build: func(b *treetest.Builder) *api.Person {
+				child := b.Person(api.Sex_MALE, "Child", "X")
+				couple(b, child)
+
+				return child
+			},
+		},
+		{
+			name: "full siblings",
+			build: func(b *treetest.Builder) *api.Person {
+				brother := b.Person(api.Sex_MALE, "Brother", "X")
+				sister := b.Person(api.Sex_FEMALE, "Sister", "X")
+				couple(b, brother, sister)
+				child := b.Person(api.Sex_MALE, "Child", "X")
+				b.Family(brother, sister, child)
+
+				return child
+			},
+			want: 1.0 / 4,
+		},
+		{
+			name: "half siblings",
+			build: func(b *treetest.Builder) *api.Person {
+				brother := b.Person(api.Sex_MALE, "Brother", "X")
+				sister := b.Person(api.Sex_FEMALE, "Sister", "X")
+				father, _ := couple(b, brother)
+				otherMother := b.Person(api.Sex_FEMALE, "Other", "X")
+				b.Family(father, otherMother, sister)
+				child := b.Person(api.Sex_MALE, "Child", "X")
+				b.Family(brother, sister, child)
+
+				return child
+			},
+			want: 1.0 / 8,
+		},
+		{
+			name: "father and daughter",
+			build: func(b *treetest.Builder) *api.Person {
+				daughter := b.Person(api.Sex_FEMALE, "Daughter", "X")
+				father, _ := couple(b, daughter)
+				child := b.Person(api.Sex_MALE, "Child", "X")
+				b.Family(father, daughter, child)
+
+				return child
+			},
+			want: 1.0 / 4,
+		},
+		{
+			name: "first cousins",
+			build: func(b *treetest.Builder) *api.Person {
+				brother := b.Person(api.Sex_MALE, "Brother", "X")
+				sister := b.Person(api.Sex_FEMALE, "Sister", "X")
+				couple(b, brother, sister)
+				cousin := b.Person(api.Sex_MALE, "Cousin", "X")
+				otherCousin := b.Person(api.Sex_FEMALE, "Cousin", "Y")
+				brotherWife := b.Person(api.Sex_FEMALE, "Wife", "X")
+				b.Family(brother, brotherWife, cousin)
+				sisterHusband := b.Person(api.Sex_MALE, "Husband", "Y")
+				b.Family(sisterHusband, sister, otherCousin)
+				child := b.Person(api.Sex_MALE, "Child", "X")
+				b.Family(cousin, otherCousin, child)
+
+				return child
+			},
+			want: 1.0 / 16,
+		},
+		{
+			name: "double first cousins",
+			build: func(b *treetest.Builder) *api.Person {
+				brother := b.Person(api.Sex_MALE, "Brother", "X")
+				sister := b.Person(api.Sex_FEMALE, "Sister", "X")
+				couple(b, brother, sister)
+				brotherWife := b.Person(api.Sex_FEMALE, "Wife", "Y")
+				sisterHusband := b.Person(api.Sex_MALE, "Husband", "Y")
+				couple(b, brotherWife, sisterHusband)
+				cousin := b.Person(api.Sex_MALE, "Cousin", "X")
+				otherCousin := b.Person(api.Sex_FEMALE, "Cousin", "Y")
+				b.Family(brother, brotherWife, cousin)
+				b.Family(sisterHusband, sister, otherCousin)
+				child := b.Person(api.Sex_MALE, "Child", "X")
+				b.Family(cousin, otherCousin, child)
+
+				return child
+			},
+			want: 1.0 / 8,
+		},
+		{
+			name: "two generations of full siblings",
+			build: func(b *treetest.Builder) *api.Person {
+				brother := b.Person(api.Sex_MALE, "Brother", "X")
+				sister := b.Person(api.Sex_FEMALE, "Sister", "X")
+				couple(b, brother, sister)
+				son := b.Person(api.Sex_MALE, "Son", "X")
+				daughter := b.Person(api.Sex_FEMALE, "Daughter", "X")
+				b.Family(brother, sister, son, daughter)
+				child := b.Person(api.Sex_MALE, "Child", "X")
+				b.Family(son, daughter, child)
+
+				return child
+			},
+			want: 3.0 / 8,
+		},
+	} {
+		b := &treetest.Builder{}
+		person := tc.build(b)
+
+		coefficients, err := Compute(b.Tree(t))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := coefficients[person.GetIndex()]; math.Abs(got-tc.want) > epsilon {
+			t.Errorf("%s: coefficient = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestComputeLoop(t *testing.T) {
+	b := &treetest.Builder{}
+	son := b.Person(api.Sex_MALE, "Son", "X")
+	father, mother := couple(b, son)
+	b.Family(son, mother, father)
+
+	if _, err := Compute(b.Tree(t)); !errors.Is(err, utils.ErrAncestryLoop) {
+		t.Errorf("Compute() error = %v, want %v", err, utils.ErrAncestryLoop)
+	}
+}
@@ -1,26 +1,41 @@
 package database
 
 import (
+	"bufio"
 	"encoding/binary"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"runtime"
+	"sync"
+	"sync/atomic"
 
 	"github.com/trois-six/geneparse/pkg/geneanet/utils"
 )
 
 type Database interface {
 	CheckPath() error
+	Open() error
+	Close() error
+	ReadNote(i int) (string, error)
+	SetPath(path string)
 	ReadIdx() error
 	ReadData() error
 	ReadIdxNote() error
 	ReadDataNote() error
+	WriteIdx() error
+	WriteData() error
+	WriteIdxNote() error
+	WriteDataNote() error
 	GetIdx() []uint32
 	GetData() [][]byte
 	GetIdxNote() []uint32
 	GetNotes() [][]utils.NoteWithTag
+	GetRawNotes() []string
+	SetNotes(notes []string)
 	Unmarshal() error
+	Marshal() error
 }
 
 type commonDatabase struct {
@@ -34,32 +49,146 @@ type commonDatabase struct {
 	data             [][]byte
 	idxNote          []uint32
 	dataNote         [][]utils.NoteWithTag
+	rawNote          []string
+	dataFile         *os.File
+	dataNoteFile     *os.File
 }
 
-func (d *commonDatabase) CheckPath() (err error) {
-	d.idxFullPath = filepath.Join(d.path, d.baseFilePrefix+".inx")
-	if !utils.FileExists(d.idxFullPath) {
-		return fmt.Errorf("%w: %s", utils.ErrFileMissing, d.idxFullPath)
+// BaseFiles returns the names of the files of a complete base: the base info,
+// and the index and data files of the persons, the families and their notes.
+func BaseFiles() []string {
+	files := []string{InfoBaseFile}
+
+	for _, prefix := range []string{personBasePrefix, familyBasePrefix} {
+		files = append(files, prefix+".inx", prefix+".dat", prefix+"_note.inx", prefix+"_note.dat")
 	}
 
+	return files
+}
+
+func (d *commonDatabase) setFullPaths() {
+	d.idxFullPath = filepath.Join(d.path, d.baseFilePrefix+".inx")
 	d.dataFullPath = filepath.Join(d.path, d.baseFilePrefix+".dat")
-	if !utils.FileExists(d.dataFullPath) {
-		return fmt.Errorf("%w: %s", utils.ErrFileMissing, d.dataFullPath)
+	d.idxNoteFullPath = filepath.Join(d.path, d.baseFilePrefix+"_note.inx")
+	d.dataNoteFullPath = filepath.Join(d.path, d.baseFilePrefix+"_note.dat")
+}
+
+func (d *commonDatabase) CheckPath() (err error) {
+	d.setFullPaths()
+
+	for _, fullPath := range []string{d.idxFullPath, d.dataFullPath, d.idxNoteFullPath, d.dataNoteFullPath} {
+		if !utils.FileExists(fullPath) {
+			return fmt.Errorf("%w: %s", utils.ErrFileMissing, fullPath)
+		}
 	}
 
-	d.idxNoteFullPath = filepath.Join(d.path, d.baseFilePrefix+"_note.inx")
-	if !utils.FileExists(d.idxNoteFullPath) {
-		return fmt.Errorf("%w: %s", utils.ErrFileMissing, d.idxNoteFullPath)
+	return nil
+}
+
+// SetPath changes the directory the database is read from and written to.
+func (d *commonDatabase) SetPath(path string) {
+	d.path = path
+	d.setFullPaths()
+}
+
+// Open prepares the database for random access: only the index files are
+// loaded, records being read on demand with readRecord and ReadNote.
+func (d *commonDatabase) Open() (err error) {
+	if err = d.CheckPath(); err != nil {
+		return err
 	}
 
-	d.dataNoteFullPath = filepath.Join(d.path, d.baseFilePrefix+"_note.dat")
-	if !utils.FileExists(d.dataNoteFullPath) {
-		return fmt.Errorf("%w: %s", utils.ErrFileMissing, d.dataNoteFullPath)
+	if err = d.ReadIdx(); err != nil {
+		return err
+	}
+
+	if err = d.ReadIdxNote(); err != nil {
+		return err
+	}
+
+	if d.dataFile, err = os.Open(d.dataFullPath); err != nil {
+		return fmt.Errorf("failed opening database data file: %w", err)
+	}
+
+	if d.dataNoteFile, err = os.Open(d.dataNoteFullPath); err != nil {
+		d.dataFile.Close()
+
+		return fmt.Errorf("failed opening database data note file: %w", err)
+	}
+
+	return nil
+}
+
+// Close releases the files opened by Open.
+func (d *commonDatabase) Close() error {
+	var errs []error
+
+	for _, f := range []*os.File{d.dataFile, d.dataNoteFile} {
+		if f != nil {
+			if err := f.Close(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	d.dataFile, d.dataNoteFile = nil, nil
+
+	if len(errs) > 0 {
+		return fmt.Errorf("failed closing database files: %w", errs[0])
 	}
 
 	return nil
 }
 
+// readAt reads the record found at offset, the offsets of the index files
+// pointing to the length prefix of each record.
+func readAt(f *os.File, offset uint32) ([]byte, error) {
+	if f == nil {
+		return nil, utils.ErrNotOpened
+	}
+
+	buf := make([]byte, utils.ConstUint32Bytes)
+	if _, err := f.ReadAt(buf, int64(offset)); err != nil {
+		return nil, fmt.Errorf(utils.ErrRead, err)
+	}
+
+	data := make([]byte, binary.BigEndian.Uint32(buf))
+	if _, err := f.ReadAt(data, int64(offset)+utils.ConstUint32Bytes); err != nil {
+		return nil, fmt.Errorf(utils.ErrRead, err)
+	}
+
+	return data, nil
+}
+
+// readRecord reads the i-th record of an opened database. It is safe for
+// concurrent use.
+func (d *commonDatabase) readRecord(i int) ([]byte, error) {
+	if i < 0 || i >= len(d.idx) {
+		return nil, fmt.Errorf("%w: %d", utils.ErrIndexOutOfRange, i)
+	}
+
+	return readAt(d.dataFile, d.idx[i])
+}
+
+// ReadNote reads the note of the i-th record of an opened database, an empty
+// string standing for a record without note. It is safe for concurrent use.
+func (d *commonDatabase) ReadNote(i int) (string, error) {
+	if i < 0 || i >= len(d.idxNote) {
+		return "", fmt.Errorf("%w: %d", utils.ErrIndexOutOfRange, i)
+	}
+
+	if d.idxNote[i] == 0 {
+		return "", nil
+	}
+
+	data, err := readAt(d.dataNoteFile, d.idxNote[i])
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
 func (d *commonDatabase) ReadIdx() (err error) {
 	d.idx, err = utils.ReadIdx(d.idxFullPath)
 	if err != nil {
@@ -106,6 +235,51 @@ func (d *commonDatabase) ReadData() (err error) {
 	return nil
 }
 
+// forEachRecord reads the records of the data file one at a time, without
+// keeping them in memory.
+func (d *commonDatabase) forEachRecord(fn func(data []byte) error) error {
+	if err := d.CheckPath(); err != nil {
+		return err
+	}
+
+	fb, err := os.Open(d.dataFullPath)
+	if err != nil {
+		return fmt.Errorf("failed opening database data file: %w", err)
+	}
+
+	defer fb.Close()
+
+	r := bufio.NewReader(fb)
+
+	buf := make([]byte, utils.ConstUint32Bytes)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return fmt.Errorf(utils.ErrRead, err)
+	}
+
+	size := binary.BigEndian.Uint32(buf)
+
+	var sizeRead uint32
+
+	for sizeRead < size {
+		dataSize, data, err := utils.ReadBytes(r)
+		if err != nil {
+			return fmt.Errorf(utils.ErrRead, err)
+		}
+
+		sizeRead += dataSize
+
+		if err = fn(data); err != nil {
+			return err
+		}
+	}
+
+	if sizeRead != size {
+		return fmt.Errorf("%w: %s", utils.ErrFileMalFormatted, d.dataFullPath)
+	}
+
+	return nil
+}
+
 func (d *commonDatabase) ReadIdxNote() (err error) {
 	d.idxNote, err = utils.ReadIdx(d.idxNoteFullPath)
 	if err != nil {
@@ -129,6 +303,7 @@ func (d *commonDatabase) ReadDataNote() (err error) {
 	}
 
 	d.dataNote = make([][]utils.NoteWithTag, len(d.idxNote))
+	d.rawNote = make([]string, len(d.idxNote))
 
 	for k := range d.idxNote {
 		if d.idxNote[k] == 0 {
@@ -140,7 +315,105 @@ func (d *commonDatabase) ReadDataNote() (err error) {
 			return fmt.Errorf(utils.ErrRead, err)
 		}
 
-		d.dataNote[k] = utils.ExplodeNote(string(data))
+		d.rawNote[k] = string(data)
+		d.dataNote[k] = utils.ExplodeNote(d.rawNote[k])
+	}
+
+	return nil
+}
+
+// writeRecords writes length-prefixed records after a leading header holding
+// their total size, and returns the offset of each record in the file, 0
+// standing for a missing record.
+func writeRecords(fileFullPath string, records [][]byte) ([]uint32, error) {
+	fb, err := os.Create(fileFullPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed creating database data file: %w", err)
+	}
+
+	defer fb.Close()
+
+	var size uint32
+
+	for _, record := range records {
+		if record != nil {
+			size += utils.ConstUint32Bytes + uint32(len(record))
+		}
+	}
+
+	w := bufio.NewWriter(fb)
+
+	if err = binary.Write(w, binary.BigEndian, size); err != nil {
+		return nil, fmt.Errorf(utils.ErrWrite, err)
+	}
+
+	idx := make([]uint32, len(records))
+	offset := uint32(utils.ConstUint32Bytes)
+
+	for k, record := range records {
+		if record == nil {
+			continue
+		}
+
+		idx[k] = offset
+
+		written, err := utils.WriteBytes(w, record)
+		if err != nil {
+			return nil, err
+		}
+
+		offset += written
+	}
+
+	if err = w.Flush(); err != nil {
+		return nil, fmt.Errorf(utils.ErrWrite, err)
+	}
+
+	if err = fb.Close(); err != nil {
+		return nil, fmt.Errorf(utils.ErrWrite, err)
+	}
+
+	return idx, nil
+}
+
+func (d *commonDatabase) WriteIdx() error {
+	if err := utils.WriteIdx(d.idxFullPath, d.idx); err != nil {
+		return fmt.Errorf("failed writing database index file: %w", err)
+	}
+
+	return nil
+}
+
+// WriteData writes the data file and computes the offsets written by WriteIdx.
+func (d *commonDatabase) WriteData() (err error) {
+	if d.idx, err = writeRecords(d.dataFullPath, d.data); err != nil {
+		return fmt.Errorf("failed writing database data file: %w", err)
+	}
+
+	return nil
+}
+
+func (d *commonDatabase) WriteIdxNote() error {
+	if err := utils.WriteIdx(d.idxNoteFullPath, d.idxNote); err != nil {
+		return fmt.Errorf("failed writing database note index file: %w", err)
+	}
+
+	return nil
+}
+
+// WriteDataNote writes the note data file and computes the offsets written by
+// WriteIdxNote.
+func (d *commonDatabase) WriteDataNote() (err error) {
+	notes := make([][]byte, len(d.rawNote))
+
+	for k, note := range d.rawNote {
+		if note != "" {
+			notes[k] = []byte(note)
+		}
+	}
+
+	if d.idxNote, err = writeRecords(d.dataNoteFullPath, notes); err != nil {
+		return fmt.Errorf("failed writing database data note file: %w", err)
 	}
 
 	return nil
@@ -162,30 +435,148 @@ func (d *commonDatabase) GetNotes() [][]utils.NoteWithTag {
 	return d.dataNote
 }
 
+func (d *commonDatabase) GetRawNotes() []string {
+	return d.rawNote
+}
+
+// SetNotes replaces the notes of the database, an empty string standing for a
+// record without note.
+func (d *commonDatabase) SetNotes(notes []string) {
+	d.rawNote = notes
+	d.dataNote = make([][]utils.NoteWithTag, len(notes))
+
+	for k, note := range notes {
+		if note != "" {
+			d.dataNote[k] = utils.ExplodeNote(note)
+		}
+	}
+}
+
+// decodeAll calls decode for every record index in [0, n), spreading the calls
+// over up to GOMAXPROCS workers. Once a call fails, the remaining records are
+// skipped and its error is returned.
+func decodeAll(n int, decode func(k int) error) error {
+	workers := runtime.GOMAXPROCS(0)
+	if workers > n {
+		workers = n
+	}
+
+	var (
+		next     int64 = -1
+		stopped  int32
+		once     sync.Once
+		firstErr error
+		wg       sync.WaitGroup
+	)
+
+	wg.Add(workers)
+
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+
+			for atomic.LoadInt32(&stopped) == 0 {
+				k := int(atomic.AddInt64(&next, 1))
+				if k >= n {
+					return
+				}
+
+				if err := decode(k); err != nil {
+					once.Do(func() {
+						firstErr = err
+						atomic.StoreInt32(&stopped, 1)
+					})
+
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	return firstErr
+}
+
+func populateDatabase(db Database) error {
+	if err := db.CheckPath(); err != nil {
+		return fmt.Errorf("failed checking databases: %w", err)
+	}
+
+	if err := db.ReadIdx(); err != nil {
+		return fmt.Errorf("failed reading index from database: %w", err)
+	}
+
+	if err := db.ReadData(); err != nil {
+		return fmt.Errorf("failed reading data from database: %w", err)
+	}
+
+	if err := db.Unmarshal(); err != nil {
+		return fmt.Errorf("failed unmarshaling data from database: %w", err)
+	}
+
+	if err := db.ReadIdxNote(); err != nil {
+		return fmt.Errorf("failed reading note index from database: %w", err)
+	}
+
+	if err := db.ReadDataNote(); err != nil {
+		return fmt.Errorf("failed reading note data from database: %w", err)
+	}
+
+	return nil
+}
+
+// PopulateDatabases loads the databases concurrently, and returns the error of
+// the first database in the list that failed.
 func PopulateDatabases(databases []Database) error {
-	for _, db := range databases {
-		if err := db.CheckPath(); err != nil {
-			return fmt.Errorf("failed checking databases: %w", err)
+	errs := make([]error, len(databases))
+
+	var wg sync.WaitGroup
+
+	wg.Add(len(databases))
+
+	for k, db := range databases {
+		go func(k int, db Database) {
+			defer wg.Done()
+
+			errs[k] = populateDatabase(db)
+		}(k, db)
+	}
+
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
 		}
+	}
 
-		if err := db.ReadIdx(); err != nil {
-			return fmt.Errorf("failed reading index from database: %w", err)
+	return nil
+}
+
+// WriteDatabases writes databases in the layout read by PopulateDatabases.
+// Reading then writing an unmodified database gives identical files, as long
+// as its records were canonically encoded.
+func WriteDatabases(databases []Database) error {
+	for _, db := range databases {
+		if err := db.Marshal(); err != nil {
+			return fmt.Errorf("failed marshaling data to database: %w", err)
 		}
 
-		if err := db.ReadData(); err != nil {
-			return fmt.Errorf("failed reading data from database: %w", err)
+		if err := db.WriteData(); err != nil {
+			return fmt.Errorf("failed writing data to database: %w", err)
 		}
 
-		if err := db.Unmarshal(); err != nil {
-			return fmt.Errorf("failed unmarshaling data from database: %w", err)
+		if err := db.WriteIdx(); err != nil {
+			return fmt.Errorf("failed writing index to database: %w", err)
 		}
 
-		if err := db.ReadIdxNote(); err != nil {
-			return fmt.Errorf("failed reading note index from database: %w", err)
+		if err := db.WriteDataNote(); err != nil {
+			return fmt.Errorf("failed writing note data to database: %w", err)
 		}
 
-		if err := db.ReadDataNote(); err != nil {
-			return fmt.Errorf("failed reading note data from database: %w", err)
+		if err := db.WriteIdxNote(); err != nil {
+			return fmt.Errorf("failed writing note index to database: %w", err)
 		}
 	}
 
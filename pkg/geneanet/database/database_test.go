@@ -0,0 +1,212 @@
+package database
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/trois-six/geneparse/pkg/geneanet/api"
+	"google.golang.org/protobuf/proto"
+)
+
+// writeTestBase writes a base of n persons, paired into couples having the
+// next couple as children, some persons and families having a note.
+func writeTestBase(t testing.TB, dir string, n int) {
+	t.Helper()
+
+	persons := make([]*api.Person, n)
+	personsNotes := make([]string, n)
+
+	for k := range persons {
+		persons[k] = &api.Person{
+			Index:      proto.Int32(int32(k)),
+			Sex:        api.Sex(k % 2).Enum(),
+			Lastname:   proto.String(fmt.Sprintf("LASTNAME%d", k/2)),
+			Firstname:  proto.String(fmt.Sprintf("Firstname%d", k)),
+			Occ:        proto.Int32(0),
+			DeathType:  api.DeathType_DONT_KNOW_IF_DEAD.Enum(),
+			BirthPlace: proto.String("Paris, 75, France"),
+			Families:   []int32{int32(k / 2)},
+		}
+
+		if k >= 2 { //nolint:gomnd
+			persons[k].Parents = proto.Int32(int32(k/2 - 1))
+		}
+
+		if k%3 == 0 {
+			personsNotes[k] = fmt.Sprintf("Note of person %d\nsecond line", k)
+		}
+	}
+
+	families := make([]*api.Family, n/2)
+	familiesNotes := make([]string, len(families))
+
+	for k := range families {
+		families[k] = &api.Family{
+			Index:        proto.Int32(int32(k)),
+			MarriageType: api.MarriageType_MARRIED.Enum(),
+			DivorceType:  api.DivorceType_NOT_DIVORCED.Enum(),
+			Father:       proto.Int32(int32(2 * k)),
+			Mother:       proto.Int32(int32(2*k + 1)),
+		}
+
+		if 2*k+3 < n {
+			families[k].Children = []int32{int32(2*k + 2), int32(2*k + 3)}
+		}
+
+		if k%2 == 0 {
+			familiesNotes[k] = fmt.Sprintf("Note of family %d", k)
+		}
+	}
+
+	person, family := NewPerson(dir), NewFamily(dir)
+	person.SetPersons(persons)
+	person.SetNotes(personsNotes)
+	family.SetFamilies(families)
+	family.SetNotes(familiesNotes)
+
+	if err := WriteDatabases([]Database{person, family}); err != nil {
+		t.Fatal(err)
+	}
+
+	info := &BaseInfo{NbPersons: uint32(n), Sosa: 1, RootSosa: 0, Timestamp: 1792388044, unknown: 0xa5}
+	if err := WriteInfoBase(dir, info); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	original, copied := t.TempDir(), t.TempDir()
+
+	writeTestBase(t, original, 50) //nolint:gomnd
+
+	person, family := NewPerson(original), NewFamily(original)
+	if err := PopulateDatabases([]Database{person, family}); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := ReadInfoBase(original)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.unknown != 0xa5 || info.Timestamp != 1792388044 || info.NbPersons != 50 {
+		t.Fatalf("base info read as %+v", *info)
+	}
+
+	person.SetPath(copied)
+	family.SetPath(copied)
+
+	if err = WriteDatabases([]Database{person, family}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = WriteInfoBase(copied, info); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(original, "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(files) != 9 { //nolint:gomnd
+		t.Fatalf("%d files written, want 9", len(files))
+	}
+
+	for _, file := range files {
+		name := filepath.Base(file)
+
+		want, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := os.ReadFile(filepath.Join(copied, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s differs after a round trip", name)
+		}
+
+		if filepath.Ext(name) == ".dat" && name != InfoBaseFile {
+			if size := binary.BigEndian.Uint32(got); int(size) != len(got)-4 {
+				t.Errorf("%s: total size header %d, want %d", name, size, len(got)-4)
+			}
+		}
+	}
+}
+
+func TestRandomAccess(t *testing.T) {
+	dir := t.TempDir()
+
+	writeTestBase(t, dir, 50) //nolint:gomnd
+
+	person := NewPerson(dir)
+	if err := person.Open(); err != nil {
+		t.Fatal(err)
+	}
+
+	defer person.Close()
+
+	for _, k := range []int{49, 0, 21} {
+		p, err := person.Get(k)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if p.GetIndex() != int32(k) || p.GetFirstname() != fmt.Sprintf("Firstname%d", k) {
+			t.Errorf("person %d read as %d %s", k, p.GetIndex(), p.GetFirstname())
+		}
+
+		note, err := person.ReadNote(k)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := ""
+		if k%3 == 0 {
+			want = fmt.Sprintf("Note of person %d\nsecond line", k)
+		}
+
+		if note != want {
+			t.Errorf("note of person %d read as %q, want %q", k, note, want)
+		}
+	}
+
+	if _, err := person.Get(50); err == nil { //nolint:gomnd
+		t.Error("person 50 read out of range")
+	}
+}
+
+// BenchmarkPopulateDatabases compares loading a generated base on a single
+// processor, decoding serially, with decoding in parallel on all of them.
+func BenchmarkPopulateDatabases(b *testing.B) {
+	dir := b.TempDir()
+
+	writeTestBase(b, dir, 50000) //nolint:gomnd
+
+	for _, bench := range []struct {
+		name  string
+		procs int
+	}{
+		{"serial", 1},
+		{"parallel", runtime.NumCPU()},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(bench.procs))
+
+			for i := 0; i < b.N; i++ {
+				if err := PopulateDatabases([]Database{NewPerson(dir), NewFamily(dir)}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
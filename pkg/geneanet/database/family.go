@@ -15,25 +15,83 @@ type Family struct {
 const familyBasePrefix = "pb_base_family"
 
 func NewFamily(path string) *Family {
-	return &Family{
+	f := &Family{
 		commonDatabase: commonDatabase{
-			path:           path,
 			baseFilePrefix: familyBasePrefix,
 		},
 	}
+
+	f.SetPath(path)
+
+	return f
 }
 
+// Unmarshal decodes the families in parallel, keeping their order.
 func (f *Family) Unmarshal() error {
-	f.families = make([]*api.Family, 0, len(f.idx))
+	f.families = make([]*api.Family, len(f.data))
+
+	err := decodeAll(len(f.data), func(k int) error {
+		family := new(api.Family)
+
+		if err := proto.Unmarshal(f.data[k], family); err != nil {
+			return fmt.Errorf("failed parsing database family data file: %w", err)
+		}
+
+		f.families[k] = family
+
+		return nil
+	})
+	if err != nil {
+		f.families = nil
+
+		return err
+	}
+
+	return nil
+}
 
-	for _, familyByte := range f.data {
+// ForEachFamily decodes the families one at a time from the data file and calls fn
+// with each of them, stopping at the first error.
+func (f *Family) ForEachFamily(fn func(*api.Family) error) error {
+	return f.forEachRecord(func(familyByte []byte) error {
 		family := new(api.Family)
 
 		if err := proto.Unmarshal(familyByte, family); err != nil {
 			return fmt.Errorf("failed parsing database family data file: %w", err)
 		}
 
-		f.families = append(f.families, family)
+		return fn(family)
+	})
+}
+
+// Get reads and decodes the family of index i from an opened database, without
+// loading the other ones. It is safe for concurrent use.
+func (f *Family) Get(i int) (*api.Family, error) {
+	familyByte, err := f.readRecord(i)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading database family %d: %w", i, err)
+	}
+
+	family := new(api.Family)
+
+	if err := proto.Unmarshal(familyByte, family); err != nil {
+		return nil, fmt.Errorf("failed parsing database family data file: %w", err)
+	}
+
+	return family, nil
+}
+
+// Marshal encodes the families, to be written by WriteData.
+func (f *Family) Marshal() error {
+	f.data = make([][]byte, 0, len(f.families))
+
+	for _, family := range f.families {
+		familyByte, err := proto.MarshalOptions{Deterministic: true}.Marshal(family)
+		if err != nil {
+			return fmt.Errorf("failed encoding database family data: %w", err)
+		}
+
+		f.data = append(f.data, familyByte)
 	}
 
 	return nil
@@ -42,3 +100,7 @@ func (f *Family) Unmarshal() error {
 func (f *Family) GetFamilies() []*api.Family {
 	return f.families
 }
+
+func (f *Family) SetFamilies(families []*api.Family) {
+	f.families = families
+}
@@ -1,6 +1,7 @@
 package database
 
 import (
+	"bufio"
 	"encoding/binary"
 	"fmt"
 	"io"
@@ -17,12 +18,19 @@ type BaseInfo struct {
 	Sosa      uint32
 	RootSosa  uint32
 	Timestamp int64
+
+	// unknown is the byte stored between Sosa and RootSosa, kept so that the
+	// base info can be written back unchanged.
+	unknown byte
 }
 
+// InfoBaseFile is the name of the base info file.
+const InfoBaseFile = "pb_base_info.dat"
+
 func ReadInfoBase(path string) (*BaseInfo, error) {
-	fileFullPath := filepath.Join(path, "pb_base_info.dat")
+	fileFullPath := filepath.Join(path, InfoBaseFile)
 	if !utils.FileExists(fileFullPath) {
-		return nil, fmt.Errorf("%w: %s", utils.ErrFileMissing, "pb_base_info.dat")
+		return nil, fmt.Errorf("%w: %s", utils.ErrFileMissing, InfoBaseFile)
 	}
 
 	f, err := os.Open(fileFullPath)
@@ -32,6 +40,13 @@ func ReadInfoBase(path string) (*BaseInfo, error) {
 
 	defer f.Close()
 
+	return DecodeInfoBase(f)
+}
+
+// DecodeInfoBase reads the base info from the content of a base info file.
+func DecodeInfoBase(f io.Reader) (*BaseInfo, error) {
+	var err error
+
 	buf := make([]byte, utils.ConstUint32Bytes)
 
 	if _, err = io.ReadFull(f, buf); err != nil {
@@ -47,8 +62,7 @@ func ReadInfoBase(path string) (*BaseInfo, error) {
 
 	b.Sosa = binary.BigEndian.Uint32(buf)
 
-	var unknown byte
-	if err = binary.Read(f, binary.BigEndian, &unknown); err != nil {
+	if err = binary.Read(f, binary.BigEndian, &b.unknown); err != nil {
 		return &b, fmt.Errorf(utils.ErrRead, err)
 	}
 
@@ -79,6 +93,38 @@ func ReadInfoBase(path string) (*BaseInfo, error) {
 	return &b, nil
 }
 
+// WriteInfoBase writes the base info file as read by ReadInfoBase.
+func WriteInfoBase(path string, b *BaseInfo) error {
+	f, err := os.Create(filepath.Join(path, InfoBaseFile))
+	if err != nil {
+		return fmt.Errorf("base info file could not be created: %w", err)
+	}
+
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+
+	for _, v := range []interface{}{b.NbPersons, b.Sosa, b.unknown, b.RootSosa} {
+		if err = binary.Write(w, binary.BigEndian, v); err != nil {
+			return fmt.Errorf(utils.ErrWrite, err)
+		}
+	}
+
+	if _, err = utils.WriteBytes(w, []byte(strconv.FormatInt(b.Timestamp, utils.ConstDecBase))); err != nil {
+		return err
+	}
+
+	if err = w.Flush(); err != nil {
+		return fmt.Errorf(utils.ErrWrite, err)
+	}
+
+	if err = f.Close(); err != nil {
+		return fmt.Errorf(utils.ErrWrite, err)
+	}
+
+	return nil
+}
+
 func GetInfoBaseString(b *BaseInfo) string {
 	return fmt.Sprintf("base info: NbPersons=%d, Sosa=%d, RootSosa=%d, Date=%s\n\n",
 		b.NbPersons, b.Sosa, b.RootSosa, time.Unix(b.Timestamp, 0))
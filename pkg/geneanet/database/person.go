@@ -15,25 +15,83 @@ type Person struct {
 const personBasePrefix = "pb_base_person"
 
 func NewPerson(path string) *Person {
-	return &Person{
+	p := &Person{
 		commonDatabase: commonDatabase{
-			path:           path,
 			baseFilePrefix: personBasePrefix,
 		},
 	}
+
+	p.SetPath(path)
+
+	return p
 }
 
+// Unmarshal decodes the persons in parallel, keeping their order.
 func (p *Person) Unmarshal() error {
-	p.persons = make([]*api.Person, 0, len(p.idx))
+	p.persons = make([]*api.Person, len(p.data))
+
+	err := decodeAll(len(p.data), func(k int) error {
+		person := new(api.Person)
+
+		if err := proto.Unmarshal(p.data[k], person); err != nil {
+			return fmt.Errorf("failed parsing database person data file: %w", err)
+		}
+
+		p.persons[k] = person
+
+		return nil
+	})
+	if err != nil {
+		p.persons = nil
+
+		return err
+	}
+
+	return nil
+}
 
-	for _, personByte := range p.data {
+// ForEachPerson decodes the persons one at a time from the data file and calls fn
+// with each of them, stopping at the first error.
+func (p *Person) ForEachPerson(fn func(*api.Person) error) error {
+	return p.forEachRecord(func(personByte []byte) error {
 		person := new(api.Person)
 
 		if err := proto.Unmarshal(personByte, person); err != nil {
 			return fmt.Errorf("failed parsing database person data file: %w", err)
 		}
 
-		p.persons = append(p.persons, person)
+		return fn(person)
+	})
+}
+
+// Get reads and decodes the person of index i from an opened database, without
+// loading the other ones. It is safe for concurrent use.
+func (p *Person) Get(i int) (*api.Person, error) {
+	personByte, err := p.readRecord(i)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading database person %d: %w", i, err)
+	}
+
+	person := new(api.Person)
+
+	if err := proto.Unmarshal(personByte, person); err != nil {
+		return nil, fmt.Errorf("failed parsing database person data file: %w", err)
+	}
+
+	return person, nil
+}
+
+// Marshal encodes the persons, to be written by WriteData.
+func (p *Person) Marshal() error {
+	p.data = make([][]byte, 0, len(p.persons))
+
+	for _, person := range p.persons {
+		personByte, err := proto.MarshalOptions{Deterministic: true}.Marshal(person)
+		if err != nil {
+			return fmt.Errorf("failed encoding database person data: %w", err)
+		}
+
+		p.data = append(p.data, personByte)
 	}
 
 	return nil
@@ -42,3 +100,7 @@ func (p *Person) Unmarshal() error {
 func (p *Person) GetPersons() []*api.Person {
 	return p.persons
 }
+
+func (p *Person) SetPersons(persons []*api.Person) {
+	p.persons = persons
+}
@@ -0,0 +1,270 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/trois-six/geneparse/pkg/geneanet/api"
+	"github.com/trois-six/geneparse/pkg/geneanet/gengedcom"
+	"github.com/trois-six/geneparse/pkg/geneanet/tree"
+)
+
+// Change is a field whose value changed between two bases. Old is empty for
+// an added value, such as an added event, and New for a removed one.
+type Change struct {
+	Field string `json:"field"`
+	Old   string `json:"old,omitempty"`
+	New   string `json:"new,omitempty"`
+}
+
+// Record is a person or a family added to, removed from or modified in the
+// second base. OldIndex is its index in the first base and NewIndex in the
+// second one, one of them being nil for an added or removed record.
+type Record struct {
+	Name     string   `json:"name"`
+	OldIndex *int32   `json:"oldIndex,omitempty"`
+	NewIndex *int32   `json:"newIndex,omitempty"`
+	Changes  []Change `json:"changes,omitempty"`
+}
+
+// Records are the persons or the families of a diff.
+type Records struct {
+	Added    []Record `json:"added"`
+	Removed  []Record `json:"removed"`
+	Modified []Record `json:"modified"`
+}
+
+// Diff holds the changes from a base to another one.
+type Diff struct {
+	Persons  Records `json:"persons"`
+	Families Records `json:"families"`
+}
+
+// Empty tells whether the bases are the same.
+func (d *Diff) Empty() bool {
+	for _, records := range []Records{d.Persons, d.Families} {
+		if len(records.Added)+len(records.Removed)+len(records.Modified) > 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// PersonName returns the Geneweb key of a person: its first name, its
+// occurrence number when not 0, and its last name.
+func PersonName(p *tree.Person) string {
+	if p == nil {
+		return "?"
+	}
+
+	if p.GetOcc() != 0 {
+		return fmt.Sprintf("%s.%d %s", p.GetFirstname(), p.GetOcc(), p.GetLastname())
+	}
+
+	return p.GetFirstname() + " " + p.GetLastname()
+}
+
+// FamilyName returns the names of the spouses of a family.
+func FamilyName(f *tree.Family) string {
+	return PersonName(f.Father()) + " & " + PersonName(f.Mother())
+}
+
+func index(i int32) *int32 {
+	return &i
+}
+
+// matching pairs the records of a base with the ones of another base, by
+// their indexes.
+type matching struct {
+	forward  map[int32]int32
+	backward map[int32]int32
+}
+
+func newMatching() *matching {
+	return &matching{forward: map[int32]int32{}, backward: map[int32]int32{}}
+}
+
+func (m *matching) add(a, b int32) {
+	m.forward[a] = b
+	m.backward[b] = a
+}
+
+func (m *matching) matched(a, b int32) bool {
+	_, okA := m.forward[a]
+	_, okB := m.backward[b]
+
+	return okA || okB
+}
+
+// match pairs the records of lengths lenA and lenB whose keys are given. Records
+// are paired when they have the same index and key, then the same key, then
+// the same index when agree tells that they share another clue, so that a
+// renamed record is still paired with itself. Records left unpaired are
+// removed or added.
+func match(lenA, lenB int, keyA, keyB func(int32) string, agree func(a, b int32) bool) *matching {
+	m := newMatching()
+
+	for i := int32(0); int(i) < lenA && int(i) < lenB; i++ {
+		if keyA(i) == keyB(i) {
+			m.add(i, i)
+		}
+	}
+
+	byKey := map[string][]int32{}
+
+	for b := int32(0); int(b) < lenB; b++ {
+		if _, ok := m.backward[b]; !ok {
+			byKey[keyB(b)] = append(byKey[keyB(b)], b)
+		}
+	}
+
+	for a := int32(0); int(a) < lenA; a++ {
+		if _, ok := m.forward[a]; ok {
+			continue
+		}
+
+		if candidates := byKey[keyA(a)]; len(candidates) > 0 {
+			m.add(a, candidates[0])
+			byKey[keyA(a)] = candidates[1:]
+		}
+	}
+
+	for i := int32(0); int(i) < lenA && int(i) < lenB; i++ {
+		if !m.matched(i, i) && agree(i, i) {
+			m.add(i, i)
+		}
+	}
+
+	return m
+}
+
+// sameDate tells whether two dates are known and written the same.
+func sameDate(a, b *api.Date) bool {
+	date := gengedcom.DateString(a)
+
+	return date != "" && date == gengedcom.DateString(b)
+}
+
+// samePerson tells whether two persons of different names were born on the
+// same date or have parents of the same names, so that one may be the other
+// renamed.
+func samePerson(a, b *tree.Person) bool {
+	if sameDate(a.GetBirthDate(), b.GetBirthDate()) {
+		return true
+	}
+
+	parentsA, parentsB := a.Parents(), b.Parents()
+
+	return parentsA != nil && parentsB != nil &&
+		PersonName(parentsA.Father()) == PersonName(parentsB.Father()) &&
+		PersonName(parentsA.Mother()) == PersonName(parentsB.Mother())
+}
+
+// sameFamily tells whether two families of different spouses keep one of
+// them or were married on the same date, so that one may be the other with a
+// spouse replaced.
+func sameFamily(a, b *tree.Family, persons map[int32]int32) bool {
+	if father, ok := persons[a.GetFather()]; ok && father == b.GetFather() {
+		return true
+	}
+
+	if mother, ok := persons[a.GetMother()]; ok && mother == b.GetMother() {
+		return true
+	}
+
+	return sameDate(a.GetMarriageDate(), b.GetMarriageDate())
+}
+
+// Compare returns the changes of the persons and the families from the base a
+// to the base b.
+func Compare(a, b *tree.Tree) *Diff {
+	personKey := func(t *tree.Tree) func(int32) string {
+		return func(i int32) string {
+			return strings.ToLower(PersonName(t.Person(i)))
+		}
+	}
+
+	persons := match(len(a.Persons()), len(b.Persons()), personKey(a), personKey(b),
+		func(i, j int32) bool { return samePerson(a.Person(i), b.Person(j)) })
+
+	// The spouses of the families of the first base are replaced by their
+	// match in the second one, so that families are matched by their spouses.
+	spouseKey := func(f *tree.Family, m map[int32]int32) string {
+		father, mother := f.GetFather(), f.GetMother()
+
+		if m != nil {
+			var okFather, okMother bool
+
+			father, okFather = m[father]
+			mother, okMother = m[mother]
+
+			if !okFather || !okMother {
+				return ""
+			}
+		}
+
+		return fmt.Sprintf("%d&%d", father, mother)
+	}
+
+	families := match(len(a.Families()), len(b.Families()),
+		func(i int32) string { return spouseKey(a.Family(i), persons.forward) },
+		func(i int32) string { return spouseKey(b.Family(i), nil) },
+		func(i, j int32) bool { return sameFamily(a.Family(i), b.Family(j), persons.forward) })
+
+	c := &comparator{a: a, b: b, persons: persons}
+	d := &Diff{
+		Persons:  Records{Added: []Record{}, Removed: []Record{}, Modified: []Record{}},
+		Families: Records{Added: []Record{}, Removed: []Record{}, Modified: []Record{}},
+	}
+
+	for _, p := range a.Persons() {
+		j, ok := persons.forward[p.GetIndex()]
+		if !ok {
+			d.Persons.Removed = append(d.Persons.Removed, Record{Name: PersonName(p), OldIndex: index(p.GetIndex())})
+
+			continue
+		}
+
+		if changes := c.person(p, b.Person(j)); len(changes) > 0 {
+			d.Persons.Modified = append(d.Persons.Modified, Record{
+				Name:     PersonName(b.Person(j)),
+				OldIndex: index(p.GetIndex()),
+				NewIndex: index(j),
+				Changes:  changes,
+			})
+		}
+	}
+
+	for _, p := range b.Persons() {
+		if _, ok := persons.backward[p.GetIndex()]; !ok {
+			d.Persons.Added = append(d.Persons.Added, Record{Name: PersonName(p), NewIndex: index(p.GetIndex())})
+		}
+	}
+
+	for _, f := range a.Families() {
+		j, ok := families.forward[f.GetIndex()]
+		if !ok {
+			d.Families.Removed = append(d.Families.Removed, Record{Name: FamilyName(f), OldIndex: index(f.GetIndex())})
+
+			continue
+		}
+
+		if changes := c.family(f, b.Family(j)); len(changes) > 0 {
+			d.Families.Modified = append(d.Families.Modified, Record{
+				Name:     FamilyName(b.Family(j)),
+				OldIndex: index(f.GetIndex()),
+				NewIndex: index(j),
+				Changes:  changes,
+			})
+		}
+	}
+
+	for _, f := range b.Families() {
+		if _, ok := families.backward[f.GetIndex()]; !ok {
+			d.Families.Added = append(d.Families.Added, Record{Name: FamilyName(f), NewIndex: index(f.GetIndex())})
+		}
+	}
+
+	return d
+}
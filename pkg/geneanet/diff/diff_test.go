@@ -0,0 +1,131 @@
+package diff
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/trois-six/geneparse/pkg/geneanet/api"
+	"github.com/trois-six/geneparse/pkg/geneanet/tree/treetest"
+)
+
+// family builds a couple with a child, the child being named and born as
+// given, its birth date being left unknown when year is 0.
+func family(b *treetest.Builder, firstname, lastname string, year int32) {
+	father := b.Person(api.Sex_MALE, "Pierre", "Martin")
+	mother := b.Person(api.Sex_FEMALE, "Marie", "Durand")
+	child := b.Person(api.Sex_MALE, firstname, lastname)
+
+	if year != 0 {
+		child.BirthDate = treetest.Date(year, 1, 1)
+	}
+
+	b.Family(father, mother, child)
+}
+
+func names(records []Record) []string {
+	list := []string{}
+
+	for _, r := range records {
+		list = append(list, r.Name)
+	}
+
+	return list
+}
+
+func TestCompare(t *testing.T) {
+	for _, tc := range []struct {
+		name                      string
+		before, after             func(b *treetest.Builder)
+		added, removed, modified  []string
+		familiesModified, changes []string
+	}{
+		{
+			name:   "same bases",
+			before: func(b *treetest.Builder) { family(b, "Jean", "Martin", 1900) },
+			after:  func(b *treetest.Builder) { family(b, "Jean", "Martin", 1900) },
+		},
+		{
+			name:     "renamed child with the same parents",
+			before:   func(b *treetest.Builder) { family(b, "Jean", "Martin", 0) },
+			after:    func(b *treetest.Builder) { family(b, "Jehan", "Martin", 0) },
+			modified: []string{"Jehan Martin"},
+			changes:  []string{"firstname"},
+		},
+		{
+			name: "renamed person with the same birth date",
+			before: func(b *treetest.Builder) {
+				b.Person(api.Sex_FEMALE, "Anne", "Leroy").BirthDate = treetest.Date(1900, 5, 4) //nolint:gomnd
+			},
+			after: func(b *treetest.Builder) {
+				b.Person(api.Sex_FEMALE, "Anna", "Le Roy").BirthDate = treetest.Date(1900, 5, 4) //nolint:gomnd
+			},
+			modified: []string{"Anna Le Roy"},
+			changes:  []string{"firstname", "lastname"},
+		},
+		{
+			name: "replaced person",
+			before: func(b *treetest.Builder) {
+				b.Person(api.Sex_FEMALE, "Anne", "Leroy").BirthDate = treetest.Date(1900, 5, 4) //nolint:gomnd
+			},
+			after: func(b *treetest.Builder) {
+				b.Person(api.Sex_MALE, "Paul", "Bernard").BirthDate = treetest.Date(1870, 2, 3) //nolint:gomnd
+			},
+			added:   []string{"Paul Bernard"},
+			removed: []string{"Anne Leroy"},
+		},
+		{
+			name:   "moved and added persons",
+			before: func(b *treetest.Builder) { family(b, "Jean", "Martin", 1900) },
+			after: func(b *treetest.Builder) {
+				b.Person(api.Sex_MALE, "Paul", "Bernard")
+				family(b, "Jean", "Martin", 1901) //nolint:gomnd
+			},
+			added:    []string{"Paul Bernard"},
+			modified: []string{"Jean Martin"},
+			changes:  []string{"birth date"},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			before, after := &treetest.Builder{}, &treetest.Builder{}
+			tc.before(before)
+			tc.after(after)
+
+			d := Compare(before.Tree(t), after.Tree(t))
+
+			for _, check := range []struct {
+				kind      string
+				got, want []string
+			}{
+				{"added", names(d.Persons.Added), tc.added},
+				{"removed", names(d.Persons.Removed), tc.removed},
+				{"modified", names(d.Persons.Modified), tc.modified},
+				{"families modified", names(d.Families.Modified), tc.familiesModified},
+			} {
+				if len(check.got)+len(check.want) > 0 && !reflect.DeepEqual(check.got, check.want) {
+					t.Errorf("%s persons = %v, want %v", check.kind, check.got, check.want)
+				}
+			}
+
+			if len(d.Families.Added)+len(d.Families.Removed) > 0 {
+				t.Errorf("families added %v, removed %v", names(d.Families.Added), names(d.Families.Removed))
+			}
+
+			var changes []string
+
+			for _, r := range d.Persons.Modified {
+				for _, c := range r.Changes {
+					changes = append(changes, c.Field)
+				}
+			}
+
+			if !reflect.DeepEqual(changes, tc.changes) {
+				t.Errorf("changes = %v, want %v", changes, tc.changes)
+			}
+
+			if empty := tc.added == nil && tc.removed == nil && tc.modified == nil; d.Empty() != empty {
+				t.Errorf("Empty() = %v, want %v", d.Empty(), empty)
+			}
+		})
+	}
+}
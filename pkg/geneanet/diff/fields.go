@@ -0,0 +1,209 @@
+package diff
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/trois-six/geneparse/pkg/geneanet/gengedcom"
+	"github.com/trois-six/geneparse/pkg/geneanet/timeline"
+	"github.com/trois-six/geneparse/pkg/geneanet/tree"
+	"github.com/trois-six/geneparse/pkg/geneanet/utils"
+)
+
+// comparator compares the records of two bases, naming the relatives of the
+// persons and the members of the families after the persons they are matched
+// with, so that records only differing by indexes are the same.
+type comparator struct {
+	a, b    *tree.Tree
+	persons *matching
+}
+
+// field is a field of a record with its value in both bases.
+type field struct {
+	name          string
+	before, after string
+}
+
+// fieldChanges returns the changes of the fields whose values differ.
+func fieldChanges(fields []field) []Change {
+	var changes []Change
+
+	for _, f := range fields {
+		if f.before != f.after {
+			changes = append(changes, Change{Field: f.name, Old: f.before, New: f.after})
+		}
+	}
+
+	return changes
+}
+
+// listChanges returns the values removed from before and added to after, as
+// changes of the field. A removed and an added value of the same kind, when
+// kind is given, are reported as a single change.
+func listChanges(name string, before, after []string, kind func(string) string) []Change {
+	count := map[string]int{}
+
+	for _, value := range before {
+		count[value]++
+	}
+
+	for _, value := range after {
+		count[value]--
+	}
+
+	var (
+		changes []Change
+		removed = map[string][]int{}
+	)
+
+	for _, value := range before {
+		if count[value] > 0 {
+			count[value]--
+			changes = append(changes, Change{Field: name, Old: value})
+
+			if kind != nil {
+				removed[kind(value)] = append(removed[kind(value)], len(changes)-1)
+			}
+		}
+	}
+
+	for _, value := range after {
+		if count[value] >= 0 {
+			continue
+		}
+
+		count[value]++
+
+		if kind != nil && len(removed[kind(value)]) > 0 {
+			changes[removed[kind(value)][0]].New = value
+			removed[kind(value)] = removed[kind(value)][1:]
+
+			continue
+		}
+
+		changes = append(changes, Change{Field: name, New: value})
+	}
+
+	return changes
+}
+
+func join(values ...string) string {
+	var nonEmpty []string
+
+	for _, value := range values {
+		if value != "" {
+			nonEmpty = append(nonEmpty, value)
+		}
+	}
+
+	return strings.Join(nonEmpty, ", ")
+}
+
+// eventKind returns the name of an event from its text.
+func eventKind(text string) string {
+	return strings.SplitN(text, ", ", 2)[0] //nolint:gomnd
+}
+
+// events returns the events of a person, as a readable text each.
+func events(p *tree.Person) []string {
+	var texts []string
+
+	for _, event := range p.GetEvents() {
+		texts = append(texts, join(timeline.EventLabel(event.GetName()), event.GetText(),
+			gengedcom.DateString(event.GetDate()), event.GetPlace(), event.GetReason(), event.GetNote(), event.GetSrc()))
+	}
+
+	return texts
+}
+
+// personName returns the name of the person of index i of the first base, or of
+// the second one when first is false. The persons of the first base are named
+// after the person they are matched with, if any.
+func (c *comparator) personName(i int32, first bool) string {
+	if !first {
+		return PersonName(c.b.Person(i))
+	}
+
+	if j, ok := c.persons.forward[i]; ok {
+		return PersonName(c.b.Person(j))
+	}
+
+	return PersonName(c.a.Person(i))
+}
+
+func (c *comparator) personNames(indexes []int32, first bool) []string {
+	names := make([]string, 0, len(indexes))
+
+	for _, i := range indexes {
+		names = append(names, c.personName(i, first))
+	}
+
+	return names
+}
+
+func (c *comparator) parents(p *tree.Person, first bool) string {
+	f := p.Parents()
+	if f == nil {
+		return ""
+	}
+
+	return c.personName(f.GetFather(), first) + " & " + c.personName(f.GetMother(), first)
+}
+
+// person returns the changes of the person from a to b.
+func (c *comparator) person(a, b *tree.Person) []Change {
+	fields := []field{
+		{"firstname", a.GetFirstname(), b.GetFirstname()},
+		{"lastname", a.GetLastname(), b.GetLastname()},
+		{"occ", itoa(a.GetOcc()), itoa(b.GetOcc())},
+		{"sex", a.GetSex().String(), b.GetSex().String()},
+		{"public name", a.GetPublicName(), b.GetPublicName()},
+		{"aliases", strings.Join(a.GetAliases(), "; "), strings.Join(b.GetAliases(), "; ")},
+		{"qualifiers", strings.Join(a.GetQualifiers(), "; "), strings.Join(b.GetQualifiers(), "; ")},
+		{"firstname aliases", strings.Join(a.GetFirstnameAliases(), "; "), strings.Join(b.GetFirstnameAliases(), "; ")},
+		{"surname aliases", strings.Join(a.GetSurnameAliases(), "; "), strings.Join(b.GetSurnameAliases(), "; ")},
+		{"birth date", gengedcom.DateString(a.GetBirthDate()), gengedcom.DateString(b.GetBirthDate())},
+		{"birth place", a.GetBirthPlace(), b.GetBirthPlace()},
+		{"birth source", a.GetBirthSrc(), b.GetBirthSrc()},
+		{"baptism date", gengedcom.DateString(a.GetBaptismDate()), gengedcom.DateString(b.GetBaptismDate())},
+		{"baptism place", a.GetBaptismPlace(), b.GetBaptismPlace()},
+		{"baptism source", a.GetBaptismSrc(), b.GetBaptismSrc()},
+		{"death type", a.GetDeathType().String(), b.GetDeathType().String()},
+		{"death date", gengedcom.DateString(a.GetDeathDate()), gengedcom.DateString(b.GetDeathDate())},
+		{"death place", a.GetDeathPlace(), b.GetDeathPlace()},
+		{"death source", a.GetDeathSrc(), b.GetDeathSrc()},
+		{"burial date", gengedcom.DateString(a.GetBurialDate()), gengedcom.DateString(b.GetBurialDate())},
+		{"burial place", a.GetBurialPlace(), b.GetBurialPlace()},
+		{"burial source", a.GetBurialSrc(), b.GetBurialSrc()},
+		{"occupation", a.GetOccupation(), b.GetOccupation()},
+		{"sources", a.GetPsources(), b.GetPsources()},
+		{"parents", c.parents(a, true), c.parents(b, false)},
+		{"note", a.Note(), b.Note()},
+	}
+
+	return append(fieldChanges(fields), listChanges("event", events(a), events(b), eventKind)...)
+}
+
+// family returns the changes of the family from a to b.
+func (c *comparator) family(a, b *tree.Family) []Change {
+	fields := []field{
+		{"father", c.personName(a.GetFather(), true), c.personName(b.GetFather(), false)},
+		{"mother", c.personName(a.GetMother(), true), c.personName(b.GetMother(), false)},
+		{"marriage type", a.GetMarriageType().String(), b.GetMarriageType().String()},
+		{"marriage date", gengedcom.DateString(a.GetMarriageDate()), gengedcom.DateString(b.GetMarriageDate())},
+		{"marriage place", a.GetMarriagePlace(), b.GetMarriagePlace()},
+		{"marriage source", a.GetMarriageSrc(), b.GetMarriageSrc()},
+		{"divorce type", a.GetDivorceType().String(), b.GetDivorceType().String()},
+		{"divorce date", gengedcom.DateString(a.GetDivorceDate()), gengedcom.DateString(b.GetDivorceDate())},
+		{"sources", a.GetFsources(), b.GetFsources()},
+		{"note", a.Note(), b.Note()},
+	}
+
+	return append(append(fieldChanges(fields),
+		listChanges("child", c.personNames(a.GetChildren(), true), c.personNames(b.GetChildren(), false), nil)...),
+		listChanges("witness", c.personNames(a.GetWitnesses(), true), c.personNames(b.GetWitnesses(), false), nil)...)
+}
+
+func itoa(i int32) string {
+	return strconv.FormatInt(int64(i), utils.ConstDecBase)
+}
@@ -0,0 +1,173 @@
+package dlextr
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// AllTrees selects all the trees of the account in SelectTrees.
+const AllTrees = "all"
+
+var (
+	errTreePair    = errors.New("tree must be an [id, label] pair")
+	errUnknownTree = errors.New("unknown tree")
+)
+
+// Tree is a tree the account can download, sent by Geneanet as an
+// [id, label] pair.
+type Tree struct {
+	ID    string
+	Label string
+}
+
+func unmarshalTreeField(data json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		return s
+	}
+
+	return strings.TrimSpace(string(data))
+}
+
+// UnmarshalJSON decodes a tree from its [id, label] pair.
+func (t *Tree) UnmarshalJSON(data []byte) error {
+	var pair []json.RawMessage
+
+	if err := json.Unmarshal(data, &pair); err != nil {
+		return fmt.Errorf(errJSONUnmarshal, err)
+	}
+
+	if len(pair) != 2 { //nolint:gomnd
+		return fmt.Errorf("%w: %s", errTreePair, string(data))
+	}
+
+	t.ID = unmarshalTreeField(pair[0])
+	t.Label = unmarshalTreeField(pair[1])
+
+	return nil
+}
+
+// MarshalJSON encodes a tree as the [id, label] pair it was decoded from.
+func (t Tree) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal([]string{t.ID, t.Label})
+	if err != nil {
+		return nil, fmt.Errorf(errJSONMarshall, err)
+	}
+
+	return data, nil
+}
+
+// Field is a value of the account information. Geneanet sends numbers, flags
+// as 0 or 1, and strings, but a field sent as another type, a boolean, or null
+// is kept as its text instead of failing the decoding.
+type Field string
+
+// UnmarshalJSON decodes a field of any JSON type.
+func (f *Field) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*f = ""
+
+		return nil
+	}
+
+	*f = Field(unmarshalTreeField(data))
+
+	return nil
+}
+
+// MarshalJSON encodes a field as a number or a boolean when it is one, and as
+// a string otherwise.
+func (f Field) MarshalJSON() ([]byte, error) {
+	if f != "" && json.Valid([]byte(f)) && !strings.ContainsAny(string(f), "\"[{") {
+		return []byte(f), nil
+	}
+
+	data, err := json.Marshal(string(f))
+	if err != nil {
+		return nil, fmt.Errorf(errJSONMarshall, err)
+	}
+
+	return data, nil
+}
+
+// Int returns the field as an integer, true being 1, and 0 when it is not a
+// number.
+func (f Field) Int() int {
+	switch f {
+	case "true":
+		return 1
+	case "false":
+		return 0
+	}
+
+	n, err := strconv.ParseFloat(string(f), 64) //nolint:gomnd
+	if err != nil {
+		return 0
+	}
+
+	return int(n)
+}
+
+// AccountInfos is the account information returned by the accountInfos
+// action. The flags are sent as 0 or 1.
+type AccountInfos struct {
+	Privilege  Field  `json:"privilege"`
+	Tree       Field  `json:"tree"`
+	OtherTrees []Tree `json:"otherTrees"`
+	TreeAccess Field  `json:"tree_access"`
+	// AnnivAsc and AnnivDesc are the numbers of generations of ancestors and
+	// descendants whose anniversaries the app lists.
+	AnnivAsc             Field `json:"annivAsc"`
+	AnnivDesc            Field `json:"annivDesc"`
+	Proprio              Field `json:"proprio"`
+	Login                Field `json:"login"`
+	CanEdit              Field `json:"canEdit"`
+	QuotaPictureExceeded Field `json:"quotaPictureExceeded"`
+}
+
+// MainTree returns the tree of the account, whose id is the tree field, or the
+// login when Geneanet does not send it, labelled with the login.
+func (a *AccountInfos) MainTree() Tree {
+	id := a.Tree
+	if id == "" {
+		id = a.Login
+	}
+
+	return Tree{ID: string(id), Label: string(a.Login)}
+}
+
+// Trees returns the trees the account can download, its own one first.
+func (a *AccountInfos) Trees() []Tree {
+	return append([]Tree{a.MainTree()}, a.OtherTrees...)
+}
+
+// SelectTrees returns the trees of the account whose ids are given, or all of
+// them when one of the ids is AllTrees.
+func (a *AccountInfos) SelectTrees(ids []string) ([]Tree, error) {
+	trees := a.Trees()
+	byID := make(map[string]Tree, len(trees))
+
+	for _, tree := range trees {
+		byID[tree.ID] = tree
+	}
+
+	selected := make([]Tree, 0, len(ids))
+
+	for _, id := range ids {
+		if id == AllTrees {
+			return trees, nil
+		}
+
+		tree, ok := byID[id]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", errUnknownTree, id)
+		}
+
+		selected = append(selected, tree)
+	}
+
+	return selected, nil
+}
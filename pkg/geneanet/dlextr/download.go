@@ -1,7 +1,7 @@
 package dlextr
 
 import (
-	"bytes"
+	"archive/zip"
 	"context"
 	"crypto/rand"
 	"encoding/hex"
@@ -12,27 +12,42 @@ import (
 	"log"
 	"net/http"
 	"net/url"
+	"os"
+	"path"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/trois-six/geneparse/pkg/geneanet/database"
+	"github.com/trois-six/geneparse/pkg/geneanet/utils"
 )
 
 const (
-	userAgent       = "GeneaNet v2.15 (Android 11 1080x2009@440)"
-	loginURL        = "https://www.geneanet.org/connexion/verify.php?ctype=id"
-	accountInfosURL = "https://www.geneanet.org/app/arbre/index.php?action=accountInfos&k=%s"
-	loggedURL       = "https://www.geneanet.org/app/arbre/index.php?action=logged"
-	importURL       = "https://www.geneanet.org/app/arbre/index.php?action=import"
-	errNewRequest   = "newRequestWithContext %q: %w"
-	errDoRequest    = "doing %q: %w"
-	errReadBody     = "reading body: %w"
-	errJSONMarshall = "json marshall: %w"
-	errIOCopy       = "io copy: %w"
-	randKLength     = 5
+	userAgent        = "GeneaNet v2.15 (Android 11 1080x2009@440)"
+	loginURL         = "https://www.geneanet.org/connexion/verify.php?ctype=id"
+	accountInfosURL  = "https://www.geneanet.org/app/arbre/index.php?action=accountInfos&k=%s"
+	loggedURL        = "https://www.geneanet.org/app/arbre/index.php?action=logged"
+	importURL        = "https://www.geneanet.org/app/arbre/index.php?action=import"
+	errNewRequest    = "newRequestWithContext %q: %w"
+	errDoRequest     = "doing %q: %w"
+	errReadBody      = "reading body: %w"
+	errJSONMarshall  = "json marshall: %w"
+	errJSONUnmarshal = "json unmarshal: %w"
+	errIOCopy        = "io copy: %w"
+	randKLength      = 5
+	// treeParam is the import form field selecting a tree other than the one
+	// of the account. It is an assumption, named after the "tree" field of the
+	// account infos, and not seen in the requests of the app: GetBase fails
+	// unless the base it receives can be told from the one of the account.
+	treeParam = "tree"
 )
 
 var (
 	errStatusCode           = errors.New("authentication status code")
 	errLoginNoSessionCookie = errors.New("no session Cookie")
+	errTreeMismatch         = errors.New("downloaded base is the one of another tree")
+	errTreeUnverified       = errors.New("downloaded base could not be verified to be the requested tree")
+	errIncompleteBase       = errors.New("downloaded archive does not hold a complete base")
 )
 
 type Download struct {
@@ -41,199 +56,348 @@ type Download struct {
 	username  string
 	password  string
 	outputDir string
-	timeout   time.Duration
-	session   string
-	reader    *bytes.Reader
-	size      int64
+	// timeout bounds the connection to Geneanet, the requests but GetBase, and
+	// the time GetBase waits for data; maxDuration bounds GetBase, if not 0.
+	timeout     time.Duration
+	maxDuration time.Duration
+	retry       RetryOptions
+	lastRequest time.Time
+	session     string
+	expires     time.Time
+	sessionFile string
+	prompt      func(username string) (string, string, error)
+	archive     *os.File
+	reader      io.ReaderAt
+	size        int64
+	// bases are the base infos of the trees downloaded or set by SetBaseInfo,
+	// by tree id, the tree of the account being "".
+	bases map[string]database.BaseInfo
 }
 
 // New initialize a Download.
 func New(username, password, outputDir string, timeout time.Duration) *Download {
 	return &Download{
 		ctx:       context.Background(),
-		client:    http.Client{},
+		client:    newClient(timeout),
 		username:  username,
 		password:  password,
 		outputDir: outputDir,
 		timeout:   timeout,
+		retry: RetryOptions{
+			Retries:    DefaultRetries,
+			MinBackoff: DefaultMinBackoff,
+			MaxBackoff: DefaultMaxBackoff,
+			Interval:   DefaultInterval,
+		},
 	}
 }
 
-func (d *Download) Login() error {
-	ctx, cancel := context.WithTimeout(d.ctx, d.timeout)
-	defer cancel()
+// SetMaxDuration bounds the duration of the download of a base, resumptions
+// included, 0 meaning no bound.
+func (d *Download) SetMaxDuration(maxDuration time.Duration) {
+	d.maxDuration = maxDuration
+}
 
+func (d *Download) Login() error {
 	data := url.Values{
 		"persistent": {"1"},
 		"login":      {d.username},
 		"password":   {d.password},
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, loginURL, strings.NewReader(data.Encode()))
-	if err != nil {
-		return fmt.Errorf(errNewRequest, loginURL, err)
-	}
+	d.session = ""
 
-	req.Header.Set("User-Agent", userAgent)
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return d.do(d.ctx, d.timeout, func(ctx context.Context) (*http.Request, error) {
+		return d.newRequest(ctx, loginURL, data)
+	}, func(resp *http.Response) error {
+		respBody, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return temporary(fmt.Errorf(errReadBody, err))
+		}
 
-	resp, err := d.client.Do(req)
-	if err != nil {
-		return fmt.Errorf(errDoRequest, loginURL, err)
+		if string(respBody) != "1" {
+			return fmt.Errorf("%w %s", errStatusCode, string(respBody))
+		}
+
+		for _, cookie := range resp.Cookies() {
+			if cookie.Name == "gntsess" {
+				d.session = cookie.Value
+
+				switch {
+				case cookie.MaxAge > 0:
+					d.expires = time.Now().Add(time.Duration(cookie.MaxAge) * time.Second)
+				case !cookie.Expires.IsZero():
+					d.expires = cookie.Expires
+				default:
+					d.expires = time.Time{}
+				}
+
+				log.Printf("Logged in as %s", d.username)
+
+				return nil
+			}
+		}
+
+		return errLoginNoSessionCookie
+	})
+}
+
+// GetAccountInfos returns the information of the logged in account.
+func (d *Download) GetAccountInfos() (*AccountInfos, error) {
+	randomBytes := make([]byte, randKLength)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return nil, fmt.Errorf("key creation error: %w", err)
 	}
 
-	defer resp.Body.Close()
+	url := fmt.Sprintf(accountInfosURL, hex.EncodeToString(randomBytes))
+
+	var infos AccountInfos
 
-	respBody, err := io.ReadAll(resp.Body)
+	err := d.do(d.ctx, d.timeout, func(ctx context.Context) (*http.Request, error) {
+		return d.newRequest(ctx, url, nil)
+	}, func(resp *http.Response) error {
+		respBody, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return temporary(fmt.Errorf(errReadBody, err))
+		}
+
+		if err = json.Unmarshal(respBody, &infos); err != nil {
+			return fmt.Errorf(errJSONUnmarshal, err)
+		}
+
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf(errReadBody, err)
+		return nil, err
 	}
 
-	if string(respBody) != "1" {
-		return fmt.Errorf("%w %s", errStatusCode, string(respBody))
-	}
+	return &infos, nil
+}
 
-	for _, cookie := range resp.Cookies() {
-		if cookie.Name == "gntsess" {
-			d.session = cookie.Value
-			log.Printf("Session cookie (gntsess) value: %s\n", d.session)
+func (d *Download) SetLogged() error {
+	return d.do(d.ctx, d.timeout, func(ctx context.Context) (*http.Request, error) {
+		return d.newRequest(ctx, loggedURL, nil)
+	}, func(resp *http.Response) error {
+		respBody, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return temporary(fmt.Errorf(errReadBody, err))
+		}
 
-			return nil
+		if string(respBody) != "1" {
+			return fmt.Errorf("%w %s", errStatusCode, string(respBody))
 		}
-	}
 
-	return errLoginNoSessionCookie
+		return nil
+	})
 }
 
-func (d *Download) GetAccountInfos() error {
-	ctx, cancel := context.WithTimeout(d.ctx, d.timeout)
+// GetBase downloads the tree of the given id, the one of the account when id
+// is empty. The timestamp of the local copy of the tree of the account, if
+// any, is sent as the sync token, so that Geneanet may send nothing when the
+// tree is unchanged; the other trees are always downloaded, to be checked.
+// The sync token is not known to be supported by Geneanet: when the archive
+// received for it does not hold a complete base, the tree is downloaded again
+// without it, and GetBase fails if it is still not complete.
+// The archive is streamed to a file of the output directory, and an
+// interrupted download is resumed where it stopped when it is retried, and by
+// a later call only when Geneanet told which version of the archive it was.
+// For another tree than the one of the account, it fails unless the base of
+// the account is known, from a previous call or from SetBaseInfo, and the base
+// received differs from it and from the ones of the other trees.
+func (d *Download) GetBase(id string, timestamp int64) error {
+	if err := d.Close(); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(d.ctx)
 	defer cancel()
 
-	randomBytes := make([]byte, randKLength)
-	if _, err := rand.Read(randomBytes); err != nil {
-		return fmt.Errorf("key creation error: %w", err)
+	if d.maxDuration > 0 {
+		ctx, cancel = context.WithTimeout(ctx, d.maxDuration)
+		defer cancel()
 	}
 
-	url := fmt.Sprintf(accountInfosURL, hex.EncodeToString(randomBytes))
+	if timestamp > 0 && id == "" {
+		err := d.download(ctx, id, strconv.FormatInt(timestamp, utils.ConstDecBase))
+		if err == nil {
+			return d.checkTree(id)
+		} else if !errors.Is(err, errIncompleteBase) {
+			return err
+		}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, strings.NewReader(""))
-	if err != nil {
-		return fmt.Errorf(errNewRequest, url, err)
+		log.Printf("No complete base sent for the sync token (%v), downloading it in full", err)
 	}
 
-	req.Header.Set("User-Agent", userAgent)
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	req.AddCookie(&http.Cookie{Name: "gntsess", Value: d.session})
-	req.AddCookie(&http.Cookie{Name: "$Version", Value: "1"})
+	if err := d.download(ctx, id, ""); err != nil {
+		return err
+	}
 
-	// dumpReq, _ := httputil.DumpRequestOut(req, true)
-	// log.Printf("%s\n\n", dumpReq)
+	return d.checkTree(id)
+}
 
-	resp, err := d.client.Do(req)
-	if err != nil {
-		return fmt.Errorf(errDoRequest, url, err)
+// download downloads the archive of the tree of the given id with the sync
+// token, and fails with errIncompleteBase if it does not hold a complete base.
+func (d *Download) download(ctx context.Context, id, syncToken string) error {
+	data := url.Values{
+		"st": {syncToken},
 	}
 
-	defer resp.Body.Close()
+	if id != "" {
+		data.Set(treeParam, id)
+	}
 
-	// dumpResp, _ := httputil.DumpResponse(resp, true)
-	// log.Printf("%s\n\n", dumpResp)
+	partial := d.archivePath(id)
 
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf(errReadBody, err)
+	if err := prepareArchive(partial); err != nil {
+		return err
 	}
 
-	var out bytes.Buffer
-
-	err = json.Indent(&out, respBody, "", "  ")
+	err := d.do(ctx, 0, func(ctx context.Context) (*http.Request, error) {
+		return d.archiveRequest(ctx, data, partial)
+	}, func(resp *http.Response) error {
+		return d.saveArchive(resp, partial)
+	})
 	if err != nil {
-		return fmt.Errorf(errJSONMarshall, err)
+		return err
 	}
 
-	log.Printf("Account infos:\n%s", out.String())
+	if err = d.openArchive(partial); err != nil {
+		return err
+	}
 
-	return nil
+	return d.complete()
 }
 
-func (d *Download) SetLogged() error {
-	ctx, cancel := context.WithTimeout(d.ctx, d.timeout)
-	defer cancel()
+// complete fails with errIncompleteBase, and closes the download, unless the
+// archive holds all the files of database.BaseFiles.
+func (d *Download) complete() error {
+	var reason string
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, loggedURL, strings.NewReader(""))
-	if err != nil {
-		return fmt.Errorf(errNewRequest, loggedURL, err)
-	}
+	if d.size == 0 {
+		reason = "empty archive"
+	} else if zr, err := zip.NewReader(d.reader, d.size); err != nil {
+		reason = err.Error()
+	} else {
+		files := map[string]bool{}
 
-	req.Header.Set("User-Agent", userAgent)
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	req.AddCookie(&http.Cookie{Name: "gntsess", Value: d.session})
-	req.AddCookie(&http.Cookie{Name: "$Version", Value: "1"})
+		for _, file := range zr.File {
+			files[path.Base(file.Name)] = true
+		}
 
-	// dumpReq, _ := httputil.DumpRequestOut(req, true)
-	// log.Printf("%s\n\n", dumpReq)
+		var missing []string
 
-	resp, err := d.client.Do(req)
-	if err != nil {
-		return fmt.Errorf(errDoRequest, loggedURL, err)
+		for _, name := range database.BaseFiles() {
+			if !files[name] {
+				missing = append(missing, name)
+			}
+		}
+
+		if len(missing) == 0 {
+			return nil
+		}
+
+		reason = "missing " + strings.Join(missing, ", ")
 	}
 
-	defer resp.Body.Close()
+	if err := d.Close(); err != nil {
+		return err
+	}
 
-	// dumpResp, _ := httputil.DumpResponse(resp, true)
-	// log.Printf("%s\n\n", dumpResp)
+	return fmt.Errorf("%w: %s", errIncompleteBase, reason)
+}
+
+// SetBaseInfo sets the base info of the tree of the given id, the one of the
+// account when id is empty, which GetBase compares to the bases downloaded for
+// the other trees.
+func (d *Download) SetBaseInfo(id string, info *database.BaseInfo) {
+	if d.bases == nil {
+		d.bases = map[string]database.BaseInfo{}
+	}
+
+	d.bases[id] = *info
+}
 
-	respBody, err := io.ReadAll(resp.Body)
+// checkTree fails when the downloaded base has the timestamp and the number of
+// persons of the base of another tree, Geneanet having then sent another tree
+// than the one of the given id. The base of another tree than the one of the
+// account is compared to the known base of the account.
+func (d *Download) checkTree(id string) error {
+	info, err := d.BaseInfo()
 	if err != nil {
-		return fmt.Errorf(errReadBody, err)
+		return err
 	}
 
-	if string(respBody) != "1" {
-		return fmt.Errorf("%w %s", errStatusCode, string(respBody))
+	if _, ok := d.bases[""]; !ok && id != "" {
+		return d.unverified(id, "the base of the account is unknown")
 	}
 
+	for other, base := range d.bases {
+		if other != id && base.Timestamp == info.Timestamp && base.NbPersons == info.NbPersons {
+			if err = d.Close(); err != nil {
+				return err
+			}
+
+			return fmt.Errorf("%w: tree %q sent as tree %q", errTreeMismatch, other, id)
+		}
+	}
+
+	d.SetBaseInfo(id, info)
+
 	return nil
 }
 
-func (d *Download) GetBase() error {
-	ctx, cancel := context.WithTimeout(d.ctx, d.timeout)
-	defer cancel()
-
-	data := url.Values{
-		"st": {""},
+// unverified closes the download of the tree of the given id, which could not
+// be checked for the given reason.
+func (d *Download) unverified(id, reason string) error {
+	if err := d.Close(); err != nil {
+		return err
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, importURL, strings.NewReader(data.Encode()))
+	return fmt.Errorf("%w: tree %q: %s", errTreeUnverified, id, reason)
+}
+
+// BaseInfo returns the base info of the downloaded base.
+func (d *Download) BaseInfo() (*database.BaseInfo, error) {
+	zr, err := zip.NewReader(d.reader, d.size)
 	if err != nil {
-		return fmt.Errorf(errNewRequest, importURL, err)
+		return nil, fmt.Errorf(errNewReader, err)
 	}
 
-	req.Header.Set("User-Agent", userAgent)
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	req.AddCookie(&http.Cookie{Name: "gntsess", Value: d.session})
-	req.AddCookie(&http.Cookie{Name: "$Version", Value: "1"})
+	for _, file := range zr.File {
+		if path.Base(file.Name) != database.InfoBaseFile {
+			continue
+		}
 
-	// dumpReq, _ := httputil.DumpRequestOut(req, true)
-	// log.Printf("%s\n\n", dumpReq)
+		f, err := file.Open()
+		if err != nil {
+			return nil, fmt.Errorf(errOpenZipFile, err)
+		}
 
-	resp, err := d.client.Do(req)
-	if err != nil {
-		return fmt.Errorf(errDoRequest, importURL, err)
-	}
+		defer f.Close()
+
+		info, err := database.DecodeInfoBase(f)
+		if err != nil {
+			return nil, fmt.Errorf("reading downloaded base info: %w", err)
+		}
 
-	defer resp.Body.Close()
+		return info, nil
+	}
 
-	// dumpResp, _ := httputil.DumpResponse(resp, true)
-	// log.Printf("%s\n\n", dumpResp)
+	return nil, fmt.Errorf("%w: %s", utils.ErrFileMissing, database.InfoBaseFile)
+}
 
-	buf := bytes.NewBuffer([]byte{})
+// Changed tells whether the downloaded base, which GetBase checked to be
+// complete, is newer than the local one of the given timestamp, comparing it
+// with the timestamp of the downloaded base info file.
+func (d *Download) Changed(localTimestamp int64) (bool, error) {
+	if localTimestamp <= 0 {
+		return true, nil
+	}
 
-	d.size, err = io.Copy(buf, resp.Body)
+	info, err := d.BaseInfo()
 	if err != nil {
-		return fmt.Errorf(errIOCopy, err)
+		return false, err
 	}
 
-	d.reader = bytes.NewReader(buf.Bytes())
-
-	return nil
+	return info.Timestamp != localTimestamp, nil
 }
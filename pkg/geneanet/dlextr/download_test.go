@@ -0,0 +1,121 @@
+package dlextr
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/trois-six/geneparse/pkg/geneanet/database"
+)
+
+// importServer sends the archives of the trees, by tree id, and answers the
+// requests with a sync token with an empty body unless synced is set.
+type importServer struct {
+	archives map[string][]byte
+	synced   bool
+
+	mu       sync.Mutex
+	requests []string
+}
+
+func (s *importServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	syncToken, id := r.PostForm.Get("st"), r.PostForm.Get(treeParam)
+
+	s.mu.Lock()
+	s.requests = append(s.requests, id+"|"+syncToken)
+	s.mu.Unlock()
+
+	if syncToken != "" && !s.synced {
+		return
+	}
+
+	w.Write(s.archives[id]) //nolint:errcheck
+}
+
+func TestGetBaseSyncToken(t *testing.T) {
+	account := &database.BaseInfo{NbPersons: 3, Timestamp: 30}
+	archives := map[string][]byte{"": testArchive(t, account)}
+
+	for _, tc := range []struct {
+		name     string
+		synced   bool
+		requests []string
+	}{
+		{"sync token ignored", true, []string{"|12"}},
+		{"nothing sent for the sync token", false, []string{"|12", "|"}},
+	} {
+		server := &importServer{archives: archives, synced: tc.synced}
+		d := newTestDownload(t, server)
+
+		if err := d.GetBase("", 12); err != nil {
+			t.Errorf("%s: GetBase() error = %v", tc.name, err)
+
+			continue
+		}
+
+		if info, err := d.BaseInfo(); err != nil || info.Timestamp != account.Timestamp {
+			t.Errorf("%s: BaseInfo() = %v, %v", tc.name, info, err)
+		}
+
+		if !reflect.DeepEqual(server.requests, tc.requests) {
+			t.Errorf("%s: requests = %q, want %q", tc.name, server.requests, tc.requests)
+		}
+
+		if err := d.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	d := newTestDownload(t, &importServer{archives: map[string][]byte{"": []byte("not an archive")}})
+	if err := d.GetBase("", 0); !errors.Is(err, errIncompleteBase) {
+		t.Errorf("GetBase() of a broken archive error = %v, want %v", err, errIncompleteBase)
+	}
+}
+
+func TestGetBaseOtherTree(t *testing.T) {
+	account := &database.BaseInfo{NbPersons: 3, Timestamp: 30}
+	other := &database.BaseInfo{NbPersons: 5, Timestamp: 50}
+
+	for _, tc := range []struct {
+		name    string
+		account *database.BaseInfo
+		sent    *database.BaseInfo
+		err     error
+	}{
+		{name: "account base unknown", sent: other, err: errTreeUnverified},
+		{name: "account base sent", account: account, sent: account, err: errTreeMismatch},
+		{name: "other base sent", account: account, sent: other},
+	} {
+		server := &importServer{archives: map[string][]byte{"other": testArchive(t, tc.sent)}}
+		d := newTestDownload(t, server)
+
+		if tc.account != nil {
+			d.SetBaseInfo("", tc.account)
+		}
+
+		err := d.GetBase("other", 0)
+		if !errors.Is(err, tc.err) || (tc.err == nil && err != nil) {
+			t.Errorf("%s: GetBase() error = %v, want %v", tc.name, err, tc.err)
+		}
+
+		if tc.err != nil && d.archive != nil {
+			t.Errorf("%s: the archive of a rejected tree was kept", tc.name)
+		}
+
+		if len(server.requests) != 1 || server.requests[0] != "other|" {
+			t.Errorf("%s: requests = %q, want the tree without sync token", tc.name, server.requests)
+		}
+
+		if err := d.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
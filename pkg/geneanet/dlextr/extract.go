@@ -17,6 +17,10 @@ const (
 	errOpenZipFile     = "opening zipped file: %w"
 	errOpenDestFile    = "opening dest file: %w"
 	errCopyZipData     = "copying data: %w"
+	errKeepPrevious    = "keeping previous version: %w"
+	// PreviousDir is the directory of the output directory of a tree the
+	// previous version of the tree is moved to when a new one is extracted.
+	PreviousDir = "previous"
 )
 
 func extract(file *zip.File, fileDst string) error {
@@ -48,8 +52,52 @@ func extract(file *zip.File, fileDst string) error {
 	return nil
 }
 
-func (d *Download) Unzip() error {
-	if err := os.MkdirAll(d.outputDir, os.ModePerm|os.ModeDir); err != nil {
+// keepPrevious moves the files of outputDir which the archive would overwrite
+// into its PreviousDir directory, replacing the version kept before.
+func keepPrevious(outputDir string, files []*zip.File) error {
+	previousDir := filepath.Join(outputDir, PreviousDir)
+
+	if err := os.RemoveAll(previousDir); err != nil {
+		return fmt.Errorf(errKeepPrevious, err)
+	}
+
+	for _, file := range files {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+
+		src := filepath.Join(outputDir, file.Name) //nolint:gosec
+		if _, err := os.Stat(src); os.IsNotExist(err) {
+			continue
+		}
+
+		dst := filepath.Join(previousDir, file.Name) //nolint:gosec
+		if err := os.MkdirAll(filepath.Dir(dst), os.ModePerm|os.ModeDir); err != nil {
+			return fmt.Errorf(errKeepPrevious, err)
+		}
+
+		if err := os.Rename(src, dst); err != nil {
+			return fmt.Errorf(errKeepPrevious, err)
+		}
+	}
+
+	return nil
+}
+
+// Unzip extracts the downloaded base into the subdir directory of the output
+// directory, the output directory itself when subdir is empty. The files it
+// replaces are kept in the PreviousDir directory of the extraction directory.
+func (d *Download) Unzip(subdir string) error {
+	return d.unzip(filepath.Join(d.outputDir, subdir), true)
+}
+
+// UnzipTo extracts the downloaded base into dir, overwriting its files.
+func (d *Download) UnzipTo(dir string) error {
+	return d.unzip(dir, false)
+}
+
+func (d *Download) unzip(outputDir string, previous bool) error {
+	if err := os.MkdirAll(outputDir, os.ModePerm|os.ModeDir); err != nil {
 		return fmt.Errorf(errCreateOutputDir, err)
 	}
 
@@ -58,10 +106,16 @@ func (d *Download) Unzip() error {
 		return fmt.Errorf(errNewReader, err)
 	}
 
+	if previous {
+		if err = keepPrevious(outputDir, zr.File); err != nil {
+			return err
+		}
+	}
+
 	for _, file := range zr.File {
 		log.Printf("Processing file: %s", file.Name)
 
-		if err := extract(file, filepath.Join(d.outputDir, file.Name)); err != nil { //nolint:gosec
+		if err := extract(file, filepath.Join(outputDir, file.Name)); err != nil { //nolint:gosec
 			return fmt.Errorf(errExtractZip, err)
 		}
 	}
@@ -0,0 +1,227 @@
+package dlextr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	mathrand "math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultRetries is the default number of retries of a failed request.
+	DefaultRetries = 3
+	// DefaultMinBackoff is the default delay before the first retry.
+	DefaultMinBackoff = time.Second
+	// DefaultMaxBackoff is the default maximum delay between two retries.
+	DefaultMaxBackoff = 30 * time.Second
+	// DefaultInterval is the default minimum interval between two requests.
+	DefaultInterval = 500 * time.Millisecond
+)
+
+var errServerStatusCode = errors.New("server status code")
+
+// RetryOptions configures how the requests to Geneanet are retried after a
+// network error or a 429 or 5xx response, and how often they are sent.
+type RetryOptions struct {
+	// Retries is the number of retries after the first attempt.
+	Retries int
+	// MinBackoff is the delay before the first retry, doubled for each other
+	// retry up to MaxBackoff, less a random jitter of up to half of it. The
+	// delay asked by a Retry-After header is used instead, unless it exceeds
+	// MaxBackoff, in which case the request fails.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// Interval is the minimum interval between the starts of two requests.
+	Interval time.Duration
+}
+
+// temporaryError is the error of an attempt of a request which is retried.
+type temporaryError struct {
+	err        error
+	retryAfter *time.Duration
+}
+
+func (e temporaryError) Error() string {
+	return e.err.Error()
+}
+
+func (e temporaryError) Unwrap() error {
+	return e.err
+}
+
+func temporary(err error) error {
+	return temporaryError{err: err}
+}
+
+// SetRetry configures the retries of the requests and their rate.
+func (d *Download) SetRetry(opts RetryOptions) {
+	d.retry = opts
+}
+
+// retryAfter returns the delay asked by the Retry-After header of the
+// response, in seconds or as a date, or nil.
+func retryAfter(resp *http.Response) *time.Duration {
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return nil
+	}
+
+	var delay time.Duration
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		delay = time.Duration(seconds) * time.Second
+	} else if date, err := http.ParseTime(value); err == nil {
+		delay = time.Until(date)
+	} else {
+		return nil
+	}
+
+	if delay < 0 {
+		delay = 0
+	}
+
+	return &delay
+}
+
+// backoff returns the delay before the given retry, from 0.
+func (d *Download) backoff(retry int) time.Duration {
+	backoff := d.retry.MaxBackoff
+	if retry < 32 && d.retry.MinBackoff<<retry > 0 && d.retry.MinBackoff<<retry < backoff { //nolint:gomnd
+		backoff = d.retry.MinBackoff << retry
+	}
+
+	if backoff <= 0 {
+		return 0
+	}
+
+	jitter := time.Duration(mathrand.Int63n(int64(backoff)/2 + 1)) //nolint:gosec,gomnd
+
+	return backoff - jitter
+}
+
+func sleep(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("waiting before request: %w", ctx.Err())
+	case <-timer.C:
+		return nil
+	}
+}
+
+// throttle waits until the interval since the previous request elapsed.
+func (d *Download) throttle(ctx context.Context) error {
+	if delay := time.Until(d.lastRequest.Add(d.retry.Interval)); delay > 0 {
+		if err := sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+
+	d.lastRequest = time.Now()
+
+	return nil
+}
+
+// newRequest returns a POST request of the form to Geneanet, sent as the app,
+// with the session cookie once logged in.
+func (d *Download) newRequest(ctx context.Context, requestURL string, form url.Values) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, requestURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf(errNewRequest, requestURL, err)
+	}
+
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	if d.session != "" {
+		req.AddCookie(&http.Cookie{Name: "gntsess", Value: d.session})
+		req.AddCookie(&http.Cookie{Name: "$Version", Value: "1"})
+	}
+
+	return req, nil
+}
+
+// attempt sends the request built by build and passes the response to handle,
+// the whole being bound by timeout when not 0.
+func (d *Download) attempt(
+	ctx context.Context,
+	timeout time.Duration,
+	build func(context.Context) (*http.Request, error),
+	handle func(*http.Response) error,
+) error {
+	if err := d.throttle(ctx); err != nil {
+		return err
+	}
+
+	if timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	req, err := build(ctx)
+	if err != nil {
+		return err
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return temporary(fmt.Errorf(errDoRequest, req.URL, err))
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError {
+		return temporaryError{
+			err:        fmt.Errorf("%w %d", errServerStatusCode, resp.StatusCode),
+			retryAfter: retryAfter(resp),
+		}
+	}
+
+	return handle(resp)
+}
+
+// do sends the request built by build and passes the response to handle,
+// retrying with an exponential backoff when the request fails with a network
+// error or a 429 or 5xx status, or when handle returns a temporary error.
+// Each attempt is bound by timeout when not 0, and all of them by ctx.
+func (d *Download) do(
+	ctx context.Context,
+	timeout time.Duration,
+	build func(context.Context) (*http.Request, error),
+	handle func(*http.Response) error,
+) error {
+	for retry := 0; ; retry++ {
+		err := d.attempt(ctx, timeout, build, handle)
+
+		var temp temporaryError
+		if err == nil || !errors.As(err, &temp) || retry >= d.retry.Retries || ctx.Err() != nil {
+			return err
+		}
+
+		delay := d.backoff(retry)
+
+		if temp.retryAfter != nil {
+			if *temp.retryAfter > d.retry.MaxBackoff {
+				return fmt.Errorf("%w, retry asked after %s", err, *temp.retryAfter)
+			}
+
+			delay = *temp.retryAfter
+		}
+
+		log.Printf("Request failed, retrying in %s: %v", delay.Round(time.Millisecond), err)
+
+		if err = sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package dlextr
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRetryAfter(t *testing.T) {
+	for _, tc := range []struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{value: ""},
+		{value: "soon"},
+		{value: "120", want: 2 * time.Minute, ok: true},
+		{value: "0", ok: true},
+		{value: "-5", ok: true},
+		{value: time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), ok: true},
+	} {
+		resp := &http.Response{Header: http.Header{}}
+		if tc.value != "" {
+			resp.Header.Set("Retry-After", tc.value)
+		}
+
+		got := retryAfter(resp)
+
+		switch {
+		case (got != nil) != tc.ok:
+			t.Errorf("retryAfter(%q) = %v, want a delay: %v", tc.value, got, tc.ok)
+		case got != nil && *got != tc.want:
+			t.Errorf("retryAfter(%q) = %s, want %s", tc.value, *got, tc.want)
+		}
+	}
+
+	resp := &http.Response{Header: http.Header{"Retry-After": {time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)}}}
+	if got := retryAfter(resp); got == nil || *got <= 59*time.Minute || *got > time.Hour {
+		t.Errorf("retryAfter() of a date in an hour = %v", got)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	d := &Download{retry: RetryOptions{MinBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}}
+
+	for _, tc := range []struct {
+		retry int
+		max   time.Duration
+	}{
+		{0, 100 * time.Millisecond},
+		{1, 200 * time.Millisecond},
+		{3, 800 * time.Millisecond},
+		{4, time.Second},
+		{40, time.Second},
+		{100, time.Second},
+	} {
+		for k := 0; k < 20; k++ {
+			if got := d.backoff(tc.retry); got < tc.max/2 || got > tc.max {
+				t.Errorf("backoff(%d) = %s, want between %s and %s", tc.retry, got, tc.max/2, tc.max)
+			}
+		}
+	}
+
+	d.retry = RetryOptions{}
+	if got := d.backoff(3); got != 0 {
+		t.Errorf("backoff() without delays = %s, want 0", got)
+	}
+}
+
+// statusServer answers the requests with the statuses in turn, the last one
+// being repeated, sending the Retry-After header when set.
+type statusServer struct {
+	statuses   []int
+	retryAfter string
+
+	mu       sync.Mutex
+	requests int
+}
+
+func (s *statusServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	status := s.statuses[len(s.statuses)-1]
+	if s.requests < len(s.statuses) {
+		status = s.statuses[s.requests]
+	}
+	s.requests++
+	s.mu.Unlock()
+
+	if s.retryAfter != "" && status != http.StatusOK {
+		w.Header().Set("Retry-After", s.retryAfter)
+	}
+
+	w.WriteHeader(status)
+	io.WriteString(w, strconv.Itoa(status)) //nolint:errcheck
+}
+
+var errNotFound = errors.New("not found")
+
+func TestDo(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		statuses   []int
+		retryAfter string
+		requests   int
+		err        error
+	}{
+		{name: "success", statuses: []int{http.StatusOK}, requests: 1},
+		{
+			name:     "retried after server errors",
+			statuses: []int{http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusOK},
+			requests: 3,
+		},
+		{
+			name:     "retries exhausted",
+			statuses: []int{http.StatusInternalServerError},
+			requests: 3,
+			err:      errServerStatusCode,
+		},
+		{
+			name:     "rate limited with Retry-After",
+			statuses: []int{http.StatusTooManyRequests, http.StatusOK}, retryAfter: "0",
+			requests: 2,
+		},
+		{
+			name:     "Retry-After too long",
+			statuses: []int{http.StatusTooManyRequests}, retryAfter: "3600",
+			requests: 1,
+			err:      errServerStatusCode,
+		},
+		{
+			name:     "error not retried",
+			statuses: []int{http.StatusNotFound},
+			requests: 1,
+			err:      errNotFound,
+		},
+	} {
+		server := &statusServer{statuses: tc.statuses, retryAfter: tc.retryAfter}
+		d := newTestDownload(t, server)
+
+		err := d.do(context.Background(), time.Second, func(ctx context.Context) (*http.Request, error) {
+			return d.newRequest(ctx, loggedURL, nil)
+		}, func(resp *http.Response) error {
+			if resp.StatusCode == http.StatusNotFound {
+				return errNotFound
+			}
+
+			return nil
+		})
+
+		if !errors.Is(err, tc.err) || (tc.err == nil && err != nil) {
+			t.Errorf("%s: do() error = %v, want %v", tc.name, err, tc.err)
+		}
+
+		if server.requests != tc.requests {
+			t.Errorf("%s: %d requests, want %d", tc.name, server.requests, tc.requests)
+		}
+	}
+}
+
+func TestDoCanceled(t *testing.T) {
+	server := &statusServer{statuses: []int{http.StatusServiceUnavailable}, retryAfter: "1"}
+	d := newTestDownload(t, server)
+	d.SetRetry(RetryOptions{Retries: 5, MaxBackoff: time.Minute})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+
+	err := d.do(ctx, 0, func(ctx context.Context) (*http.Request, error) {
+		return d.newRequest(ctx, loggedURL, nil)
+	}, func(*http.Response) error { return nil })
+
+	if !errors.Is(err, context.DeadlineExceeded) || time.Since(start) > time.Second {
+		t.Errorf("do() error = %v after %s, want %v at once", err, time.Since(start), context.DeadlineExceeded)
+	}
+
+	if server.requests != 1 {
+		t.Errorf("%d requests, want 1", server.requests)
+	}
+}
+
+func TestThrottle(t *testing.T) {
+	d := &Download{retry: RetryOptions{Interval: 50 * time.Millisecond}}
+	start := time.Now()
+
+	for k := 0; k < 3; k++ {
+		if err := d.throttle(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("3 requests sent in %s, want at least %s", elapsed, 100*time.Millisecond)
+	}
+}
@@ -0,0 +1,185 @@
+package dlextr
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/trois-six/geneparse/pkg/geneanet/utils"
+)
+
+const (
+	sessionDir  = "geneparse"
+	sessionFile = "session.json"
+)
+
+// savedSession is the content of the session file: the gntsess cookie of the
+// logged in account, and when it expires, if Geneanet told it.
+type savedSession struct {
+	Username string    `json:"username"`
+	Session  string    `json:"gntsess"`
+	Expires  time.Time `json:"expires,omitempty"`
+}
+
+// DefaultSessionFile returns the path of the session file in the configuration
+// directory of the user.
+func DefaultSessionFile() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("locating session file: %w", err)
+	}
+
+	return filepath.Join(dir, sessionDir, sessionFile), nil
+}
+
+// SetSessionFile makes Authenticate reuse the session saved in the file, and
+// save the new sessions to it. An empty path disables the session file.
+func (d *Download) SetSessionFile(path string) {
+	d.sessionFile = path
+}
+
+// loadSession reads the session saved in the session file, and tells whether
+// it may be reused: it belongs to the username, if set, and has not expired.
+func (d *Download) loadSession() (bool, error) {
+	if d.sessionFile == "" {
+		return false, nil
+	}
+
+	content, err := os.ReadFile(d.sessionFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf(utils.ErrRead, err)
+	}
+
+	var saved savedSession
+
+	if err = json.Unmarshal(content, &saved); err != nil {
+		return false, fmt.Errorf("%w: %s: %v", utils.ErrFileMalFormatted, d.sessionFile, err)
+	}
+
+	switch {
+	case saved.Session == "":
+		return false, nil
+	case d.username != "" && saved.Username != d.username:
+		return false, nil
+	case !saved.Expires.IsZero() && time.Now().After(saved.Expires):
+		log.Printf("Saved session expired on %s", saved.Expires.Format(time.RFC3339))
+
+		return false, nil
+	}
+
+	d.username, d.session, d.expires = saved.Username, saved.Session, saved.Expires
+
+	return true, nil
+}
+
+// SaveSession writes the session to the session file, readable by the user
+// only, if any.
+func (d *Download) SaveSession() error {
+	if d.sessionFile == "" {
+		return nil
+	}
+
+	content, err := json.MarshalIndent(savedSession{Username: d.username, Session: d.session, Expires: d.expires}, "", "  ")
+	if err != nil {
+		return fmt.Errorf(errJSONMarshall, err)
+	}
+
+	if err = os.MkdirAll(filepath.Dir(d.sessionFile), 0o700); err != nil { //nolint:gomnd
+		return fmt.Errorf("creating session directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(d.sessionFile), "."+filepath.Base(d.sessionFile)+"-*")
+	if err != nil {
+		return fmt.Errorf("saving session: %w", err)
+	}
+
+	defer os.Remove(tmp.Name())
+
+	// CreateTemp creates the file with the 0600 permissions.
+	if _, err = tmp.Write(content); err != nil {
+		tmp.Close()
+
+		return fmt.Errorf(utils.ErrWrite, err)
+	}
+
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf(utils.ErrWrite, err)
+	}
+
+	if err = os.Rename(tmp.Name(), d.sessionFile); err != nil {
+		return fmt.Errorf("saving session: %w", err)
+	}
+
+	return nil
+}
+
+// RemoveSession removes the session file, if any.
+func RemoveSession(path string) (bool, error) {
+	if err := os.Remove(path); errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("removing session: %w", err)
+	}
+
+	return true, nil
+}
+
+// SetPrompt makes Authenticate ask the username, when unknown, and the
+// password with prompt when it has to log in without them.
+func (d *Download) SetPrompt(prompt func(username string) (string, string, error)) {
+	d.prompt = prompt
+}
+
+// Authenticate reuses the session saved in the session file when it has not
+// expired and Geneanet still accepts it, and logs in with the username and the
+// password otherwise, saving the new session. Either way, the session is set
+// as logged, as SetLogged does.
+func (d *Download) Authenticate() error {
+	reuse, err := d.loadSession()
+	if err != nil {
+		return err
+	}
+
+	if reuse {
+		err = d.SetLogged()
+		if err == nil {
+			log.Printf("Reusing the saved session of %s", d.username)
+
+			return nil
+		}
+
+		if !errors.Is(err, errStatusCode) {
+			return err
+		}
+
+		log.Printf("Saved session rejected, logging in again")
+
+		d.session, d.expires = "", time.Time{}
+	}
+
+	if (d.username == "" || d.password == "") && d.prompt != nil {
+		if d.username, d.password, err = d.prompt(d.username); err != nil {
+			return err
+		}
+	}
+
+	if d.username == "" || d.password == "" {
+		return utils.ErrNoCredentials
+	}
+
+	if err = d.Login(); err != nil {
+		return err
+	}
+
+	if err = d.SaveSession(); err != nil {
+		return err
+	}
+
+	return d.SetLogged()
+}
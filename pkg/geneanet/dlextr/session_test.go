@@ -0,0 +1,172 @@
+package dlextr
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/trois-six/geneparse/pkg/geneanet/utils"
+)
+
+func TestSessionFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "geneparse", "session.json")
+	expires := time.Now().Add(time.Hour).Round(time.Second)
+
+	d := New("user", "", "", time.Second)
+	d.SetSessionFile(path)
+	d.session, d.expires = "cookie", expires
+
+	if err := d.SaveSession(); err != nil {
+		t.Fatal(err)
+	}
+
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("session file mode = %v, %v, want 0600", info.Mode().Perm(), err)
+	}
+
+	for _, tc := range []struct {
+		name     string
+		username string
+		reused   bool
+	}{
+		{"same user", "user", true},
+		{"any user", "", true},
+		{"other user", "other", false},
+	} {
+		loaded := New(tc.username, "", "", time.Second)
+		loaded.SetSessionFile(path)
+
+		reused, err := loaded.loadSession()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if reused != tc.reused {
+			t.Errorf("%s: loadSession() = %v, want %v", tc.name, reused, tc.reused)
+		}
+
+		if reused && (loaded.username != "user" || loaded.session != "cookie" || !loaded.expires.Equal(expires)) {
+			t.Errorf("%s: loaded %q, %q, %s", tc.name, loaded.username, loaded.session, loaded.expires)
+		}
+	}
+
+	d.expires = time.Now().Add(-time.Minute)
+	if err := d.SaveSession(); err != nil {
+		t.Fatal(err)
+	}
+
+	if reused, err := d.loadSession(); reused || err != nil {
+		t.Errorf("loadSession() of an expired session = %v, %v, want false", reused, err)
+	}
+
+	if err := os.WriteFile(path, []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := d.loadSession(); !errors.Is(err, utils.ErrFileMalFormatted) {
+		t.Errorf("loadSession() error = %v, want %v", err, utils.ErrFileMalFormatted)
+	}
+
+	for _, want := range []bool{true, false} {
+		if removed, err := RemoveSession(path); removed != want || err != nil {
+			t.Errorf("RemoveSession() = %v, %v, want %v", removed, err, want)
+		}
+	}
+}
+
+// sessionServer accepts the session "valid", and logs in the password
+// "secret" with a new session.
+type sessionServer struct {
+	mu     sync.Mutex
+	logins int
+}
+
+func (s *sessionServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/connexion/verify.php" {
+		s.mu.Lock()
+		s.logins++
+		s.mu.Unlock()
+
+		if err := r.ParseForm(); err != nil || r.PostForm.Get("password") != "secret" {
+			io.WriteString(w, "0") //nolint:errcheck
+
+			return
+		}
+
+		http.SetCookie(w, &http.Cookie{Name: "gntsess", Value: "valid", MaxAge: 3600}) //nolint:gomnd
+		io.WriteString(w, "1")                                                         //nolint:errcheck
+
+		return
+	}
+
+	if cookie, err := r.Cookie("gntsess"); err == nil && cookie.Value == "valid" {
+		io.WriteString(w, "1") //nolint:errcheck
+
+		return
+	}
+
+	io.WriteString(w, "0") //nolint:errcheck
+}
+
+func TestAuthenticate(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		saved    string
+		password string
+		prompt   bool
+		logins   int
+		err      error
+	}{
+		{name: "saved session reused", saved: "valid"},
+		{name: "saved session rejected", saved: "stale", password: "secret", logins: 1},
+		{name: "no saved session", password: "secret", logins: 1},
+		{name: "password prompted", prompt: true, logins: 1},
+		{name: "no credentials", saved: "stale", err: utils.ErrNoCredentials},
+		{name: "wrong password", password: "wrong", logins: 1, err: errStatusCode},
+	} {
+		server := &sessionServer{}
+		d := newTestDownload(t, server)
+		d.password = tc.password
+		d.SetSessionFile(filepath.Join(t.TempDir(), "session.json"))
+
+		if tc.saved != "" {
+			saved := newTestDownload(t, server)
+			saved.SetSessionFile(d.sessionFile)
+			saved.session = tc.saved
+
+			if err := saved.SaveSession(); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if tc.prompt {
+			d.SetPrompt(func(username string) (string, string, error) { return username, "secret", nil })
+		}
+
+		if err := d.Authenticate(); !errors.Is(err, tc.err) || (tc.err == nil && err != nil) {
+			t.Errorf("%s: Authenticate() error = %v, want %v", tc.name, err, tc.err)
+
+			continue
+		}
+
+		if server.logins != tc.logins {
+			t.Errorf("%s: %d logins, want %d", tc.name, server.logins, tc.logins)
+		}
+
+		if tc.err != nil {
+			continue
+		}
+
+		reloaded := New("user", "", "", time.Second)
+		reloaded.SetSessionFile(d.sessionFile)
+
+		if reused, err := reloaded.loadSession(); !reused || err != nil || reloaded.session != "valid" {
+			t.Errorf("%s: saved session %q, %v, %v", tc.name, reloaded.session, reused, err)
+		}
+	}
+}
@@ -0,0 +1,322 @@
+package dlextr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	archiveSuffix    = ".zip"
+	partialSuffix    = ".part"
+	validatorSuffix  = ".validator"
+	progressInterval = 5 * time.Second
+	bytesPerMiB      = 1 << 20
+)
+
+var (
+	errDownloadStatusCode = errors.New("download status code")
+	errRangeNotSatisfied  = errors.New("partial download could not be resumed")
+	errRangeMismatch      = errors.New("partial download resumed at the wrong offset")
+)
+
+// newClient returns an HTTP client waiting at most timeout to connect and to
+// receive the response headers. The duration of the whole request is bound by
+// the context of each request.
+func newClient(timeout time.Duration) http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+
+	return http.Client{
+		Transport: &http.Transport{
+			Proxy:                 http.ProxyFromEnvironment,
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   timeout,
+			ResponseHeaderTimeout: timeout,
+		},
+	}
+}
+
+// idleReader resets its timer whenever something is read, so that it fires
+// when nothing was read for timeout.
+type idleReader struct {
+	r       io.Reader
+	timer   *time.Timer
+	timeout time.Duration
+}
+
+func (r *idleReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if n > 0 {
+		r.timer.Reset(r.timeout)
+	}
+
+	return n, err //nolint:wrapcheck
+}
+
+// progress logs the number of bytes written to it regularly.
+type progress struct {
+	name    string
+	written int64
+	total   int64
+	last    time.Time
+}
+
+func (p *progress) Write(b []byte) (int, error) {
+	p.written += int64(len(b))
+
+	if time.Since(p.last) >= progressInterval {
+		p.log()
+	}
+
+	return len(b), nil
+}
+
+func (p *progress) log() {
+	p.last = time.Now()
+
+	if p.total > 0 {
+		log.Printf("Downloading %s: %.1f / %.1f MiB (%.0f%%)", p.name,
+			float64(p.written)/bytesPerMiB, float64(p.total)/bytesPerMiB, float64(p.written)*100/float64(p.total)) //nolint:gomnd
+	} else {
+		log.Printf("Downloading %s: %.1f MiB", p.name, float64(p.written)/bytesPerMiB)
+	}
+}
+
+// archivePath returns the path the archive of the tree of the given id is
+// downloaded to, before it is complete.
+func (d *Download) archivePath(id string) string {
+	if id == "" {
+		id = "base"
+	}
+
+	return filepath.Join(d.outputDir, "."+id+archiveSuffix+partialSuffix)
+}
+
+// validator returns the ETag, or else the Last-Modified date, of the response,
+// which tells whether a later response sends the same archive. A weak ETag
+// cannot be used in If-Range.
+func validator(resp *http.Response) string {
+	if etag := resp.Header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		return etag
+	}
+
+	return resp.Header.Get("Last-Modified")
+}
+
+// readValidator returns the validator of the archive the partial file is the
+// beginning of, none if it is unknown.
+func readValidator(partial string) string {
+	content, err := os.ReadFile(partial + validatorSuffix)
+	if err != nil {
+		return ""
+	}
+
+	return string(content)
+}
+
+// prepareArchive truncates the partial file left by a previous download when
+// the archive it was downloaded from is unknown, so that it is not resumed
+// with the bytes of another version of the archive.
+func prepareArchive(partial string) error {
+	if readValidator(partial) != "" {
+		return nil
+	}
+
+	if err := os.Truncate(partial, 0); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("restarting partial download: %w", err)
+	}
+
+	return nil
+}
+
+// archiveRequest returns the request of the archive, resuming the download
+// from the end of the partial file when it is not empty. The range is only
+// sent if the archive is still the one of the validator, when it is known.
+func (d *Download) archiveRequest(ctx context.Context, data url.Values, partial string) (*http.Request, error) {
+	req, err := d.newRequest(ctx, importURL, data)
+	if err != nil {
+		return nil, err
+	}
+
+	if info, err := os.Stat(partial); err == nil && info.Size() > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", info.Size()))
+
+		if v := readValidator(partial); v != "" {
+			req.Header.Set("If-Range", v)
+		}
+	}
+
+	return req, nil
+}
+
+// rangeStart returns the first byte of the Content-Range of the response.
+func rangeStart(resp *http.Response) (int64, error) {
+	var start, end int64
+
+	contentRange := resp.Header.Get("Content-Range")
+	if _, err := fmt.Sscanf(contentRange, "bytes %d-%d/", &start, &end); err != nil {
+		return 0, fmt.Errorf("%w: Content-Range %q", errRangeMismatch, contentRange)
+	}
+
+	return start, nil
+}
+
+// restart empties the partial file and saves the validator of the response,
+// which sends the whole archive.
+func restart(f *os.File, resp *http.Response, partial string) error {
+	err := f.Truncate(0)
+	if err == nil {
+		_, err = f.Seek(0, io.SeekStart)
+	}
+
+	if err != nil {
+		return fmt.Errorf("restarting partial download: %w", err)
+	}
+
+	if v := validator(resp); v != "" {
+		err = os.WriteFile(partial+validatorSuffix, []byte(v), 0o600) //nolint:gomnd
+	} else {
+		err = os.Remove(partial + validatorSuffix)
+		if errors.Is(err, os.ErrNotExist) {
+			err = nil
+		}
+	}
+
+	if err != nil {
+		return fmt.Errorf("saving download validator: %w", err)
+	}
+
+	return nil
+}
+
+// saveArchive appends the archive sent in the response to the partial file,
+// or replaces its content when the download could not be resumed. An
+// interrupted download, or one resumed at another offset than the end of the
+// partial file, is a temporary error, so that it is resumed or restarted.
+func (d *Download) saveArchive(resp *http.Response, partial string) error {
+	f, err := os.OpenFile(partial, os.O_CREATE|os.O_WRONLY, 0o600) //nolint:gomnd
+	if err != nil {
+		return fmt.Errorf("opening partial download: %w", err)
+	}
+
+	defer f.Close()
+
+	offset, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return fmt.Errorf("opening partial download: %w", err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		var start int64
+
+		if start, err = rangeStart(resp); err == nil && start != offset {
+			err = fmt.Errorf("%w: %d instead of %d", errRangeMismatch, start, offset)
+		}
+
+		if err != nil {
+			if truncateErr := f.Truncate(0); truncateErr != nil {
+				return fmt.Errorf("restarting partial download: %w", truncateErr)
+			}
+
+			return temporary(err)
+		}
+
+		log.Printf("Resuming download at %d bytes", offset)
+	case http.StatusOK:
+		// The whole archive is sent again when the range is not supported, or
+		// when the archive changed since the partial file was downloaded.
+		if err = restart(f, resp, partial); err != nil {
+			return err
+		}
+
+		offset = 0
+	case http.StatusRequestedRangeNotSatisfiable:
+		if err = f.Truncate(0); err != nil {
+			return fmt.Errorf("restarting partial download: %w", err)
+		}
+
+		return temporary(errRangeNotSatisfied)
+	default:
+		return fmt.Errorf("%w %d", errDownloadStatusCode, resp.StatusCode)
+	}
+
+	p := &progress{name: filepath.Base(partial), written: offset, last: time.Now()}
+	if resp.ContentLength >= 0 {
+		p.total = offset + resp.ContentLength
+	}
+
+	// Closing the body interrupts the copy when nothing is received.
+	timer := time.AfterFunc(d.timeout, func() { resp.Body.Close() })
+	defer timer.Stop()
+
+	if _, err = io.Copy(io.MultiWriter(f, p), &idleReader{r: resp.Body, timer: timer, timeout: d.timeout}); err != nil {
+		return temporary(fmt.Errorf(errIOCopy, err))
+	}
+
+	p.log()
+
+	if err = f.Close(); err != nil {
+		return fmt.Errorf(errIOCopy, err)
+	}
+
+	return nil
+}
+
+// openArchive makes the complete download the archive read by Unzip.
+func (d *Download) openArchive(partial string) error {
+	archive := strings.TrimSuffix(partial, partialSuffix)
+
+	if err := os.Rename(partial, archive); err != nil {
+		return fmt.Errorf("completing download: %w", err)
+	}
+
+	if err := os.Remove(partial + validatorSuffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("completing download: %w", err)
+	}
+
+	f, err := os.Open(archive)
+	if err != nil {
+		return fmt.Errorf("opening download: %w", err)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+
+		return fmt.Errorf("opening download: %w", err)
+	}
+
+	d.archive, d.reader, d.size = f, f, info.Size()
+
+	return nil
+}
+
+// Close removes the archive downloaded by GetBase.
+func (d *Download) Close() error {
+	if d.archive == nil {
+		return nil
+	}
+
+	archive := d.archive
+	d.archive, d.reader, d.size = nil, nil, 0
+
+	if err := archive.Close(); err != nil {
+		return fmt.Errorf("closing download: %w", err)
+	}
+
+	if err := os.Remove(archive.Name()); err != nil {
+		return fmt.Errorf("removing download: %w", err)
+	}
+
+	return nil
+}
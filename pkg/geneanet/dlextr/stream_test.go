@@ -0,0 +1,239 @@
+package dlextr
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/trois-six/geneparse/pkg/geneanet/database"
+)
+
+const testETag = `"v1"`
+
+// rewrite sends all the requests to the test server instead of Geneanet.
+type rewrite struct {
+	target *url.URL
+	next   http.RoundTripper
+}
+
+func (r rewrite) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme, req.URL.Host = r.target.Scheme, r.target.Host
+
+	return r.next.RoundTrip(req) //nolint:wrapcheck
+}
+
+// newTestDownload returns a download whose requests are handled by handler,
+// retried twice without waiting.
+func newTestDownload(t *testing.T, handler http.Handler) *Download {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	target, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := New("user", "password", t.TempDir(), 5*time.Second) //nolint:gomnd
+	d.client = http.Client{Transport: rewrite{target: target, next: server.Client().Transport}}
+	d.SetRetry(RetryOptions{Retries: 2, MinBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond}) //nolint:gomnd
+
+	return d
+}
+
+// testArchive returns an archive holding all the files of a base with the
+// base info, stored without compression so that it is large enough to be
+// downloaded in parts.
+func testArchive(t *testing.T, info *database.BaseInfo) []byte {
+	t.Helper()
+
+	dir := t.TempDir()
+	if err := database.WriteInfoBase(dir, info); err != nil {
+		t.Fatal(err)
+	}
+
+	infoContent, err := os.ReadFile(filepath.Join(dir, database.InfoBaseFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+
+	zw := zip.NewWriter(&buf)
+
+	for _, name := range database.BaseFiles() {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: "base/" + name, Method: zip.Store})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		content := bytes.Repeat([]byte(name), 50) //nolint:gomnd
+		if name == database.InfoBaseFile {
+			content = infoContent
+		}
+
+		if _, err = w.Write(content); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+// archiveServer serves an archive, supporting Range and If-Range requests.
+type archiveServer struct {
+	archive []byte
+	etag    string
+	// cut interrupts the first response after that many bytes, if not 0.
+	cut int
+	// shift moves the start of the first partial response by that many bytes.
+	shift int
+
+	mu       sync.Mutex
+	requests []string
+}
+
+func (s *archiveServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests = append(s.requests, r.Header.Get("Range")+"|"+r.Header.Get("If-Range"))
+	first := len(s.requests) == 1
+	s.mu.Unlock()
+
+	w.Header().Set("ETag", s.etag)
+
+	var start int
+
+	ifRange := r.Header.Get("If-Range")
+	if _, err := fmt.Sscanf(r.Header.Get("Range"), "bytes=%d-", &start); err == nil && (ifRange == "" || ifRange == s.etag) {
+		if start >= len(s.archive) {
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", len(s.archive)))
+			w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+
+			return
+		}
+
+		sent := start
+		if first {
+			sent += s.shift
+		}
+
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", sent, len(s.archive)-1, len(s.archive)))
+		w.Header().Set("Content-Length", fmt.Sprint(len(s.archive)-start))
+		w.WriteHeader(http.StatusPartialContent)
+		w.Write(s.archive[start:]) //nolint:errcheck
+
+		return
+	}
+
+	w.Header().Set("Content-Length", fmt.Sprint(len(s.archive)))
+
+	if first && s.cut > 0 {
+		w.Write(s.archive[:s.cut])  //nolint:errcheck
+		w.(http.Flusher).Flush()    //nolint:forcetypeassert
+		panic(http.ErrAbortHandler) //nolint:forbidigo
+	}
+
+	w.Write(s.archive) //nolint:errcheck
+}
+
+func TestDownloadResume(t *testing.T) {
+	archive := testArchive(t, &database.BaseInfo{NbPersons: 1, Timestamp: 1})
+	longer := append(append([]byte{}, archive...), "trailing"...)
+
+	for _, tc := range []struct {
+		name      string
+		partial   []byte
+		validator string
+		cut       int
+		shift     int
+		requests  []string
+	}{
+		{name: "new download", requests: []string{"|"}},
+		{
+			name:    "resumed",
+			partial: archive[:100], validator: testETag,
+			requests: []string{"bytes=100-|" + testETag},
+		},
+		{
+			name:    "archive changed",
+			partial: archive[:100], validator: `"v0"`,
+			requests: []string{`bytes=100-|"v0"`},
+		},
+		{
+			name:     "unknown archive",
+			partial:  []byte("something else"),
+			requests: []string{"|"},
+		},
+		{
+			name:    "range not satisfiable",
+			partial: longer, validator: testETag,
+			requests: []string{fmt.Sprintf("bytes=%d-|%s", len(longer), testETag), "|"},
+		},
+		{
+			name:     "interrupted",
+			cut:      100,
+			requests: []string{"|", "bytes=100-|" + testETag},
+		},
+		{
+			name:    "resumed at the wrong offset",
+			partial: archive[:100], validator: testETag, shift: 10,
+			requests: []string{"bytes=100-|" + testETag, "|"},
+		},
+	} {
+		server := &archiveServer{archive: archive, etag: testETag, cut: tc.cut, shift: tc.shift}
+		d := newTestDownload(t, server)
+		partial := d.archivePath("")
+
+		if tc.partial != nil {
+			if err := os.WriteFile(partial, tc.partial, 0o600); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if tc.validator != "" {
+			if err := os.WriteFile(partial+validatorSuffix, []byte(tc.validator), 0o600); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if err := d.download(context.Background(), "", ""); err != nil {
+			t.Errorf("%s: download() error = %v", tc.name, err)
+
+			continue
+		}
+
+		if !reflect.DeepEqual(server.requests, tc.requests) {
+			t.Errorf("%s: requests = %q, want %q", tc.name, server.requests, tc.requests)
+		}
+
+		if content, err := os.ReadFile(d.archive.Name()); err != nil || !bytes.Equal(content, archive) || d.size != int64(len(archive)) {
+			t.Errorf("%s: downloaded %d bytes, want %d (%v)", tc.name, len(content), len(archive), err)
+		}
+
+		for _, name := range []string{partial, partial + validatorSuffix} {
+			if _, err := os.Stat(name); !os.IsNotExist(err) {
+				t.Errorf("%s: %s was not removed", tc.name, filepath.Base(name))
+			}
+		}
+
+		if err := d.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,323 @@
+package duplicate
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/trois-six/geneparse/pkg/geneanet/api"
+	"github.com/trois-six/geneparse/pkg/geneanet/tree"
+	"github.com/trois-six/geneparse/pkg/geneanet/utils"
+)
+
+// Weights of the clues, their sum may exceed 1 as the score is capped.
+const (
+	weightSameLastname     = 0.3
+	weightSimilarLastname  = 0.2
+	weightSameFirstname    = 0.3
+	weightSimilarFirstname = 0.2
+	weightSimilarGivenName = 0.1
+	weightSameBirthYear    = 0.15
+	weightNearBirthYear    = 0.1
+	weightCloseBirthYear   = 0.05
+	weightSameDeathYear    = 0.1
+	weightNearDeathYear    = 0.05
+	weightSameParents      = 0.15
+	weightSimilarParents   = 0.1
+	weightSameSpouse       = 0.1
+
+	nearYears  = 2
+	closeYears = 5
+
+	maxScore      = 1.0
+	scoreRounding = 100
+
+	// DefaultThreshold is the score under which pairs are not reported.
+	DefaultThreshold = 0.7
+	// DefaultMaxYearGap is the difference between two birth or death years over
+	// which two persons cannot be the same.
+	DefaultMaxYearGap = 10
+)
+
+// Options tunes the search of duplicates.
+type Options struct {
+	Threshold  float64
+	MaxYearGap int32
+}
+
+// DefaultOptions returns the options used by the duplicates command.
+func DefaultOptions() Options {
+	return Options{
+		Threshold:  DefaultThreshold,
+		MaxYearGap: DefaultMaxYearGap,
+	}
+}
+
+// Candidate is a pair of persons which may be the same, with the clues which
+// led to its score.
+type Candidate struct {
+	A       int32    `json:"a"`
+	B       int32    `json:"b"`
+	Score   float64  `json:"score"`
+	Reasons []string `json:"reasons"`
+}
+
+// key holds the normalised names of a person, computed once.
+type key struct {
+	person    *tree.Person
+	lastname  string
+	firstname string
+	lastCode  string
+	firstCode string
+	// firstToken is the code of the first of the first names only
+	firstToken string
+}
+
+func newKey(p *tree.Person) *key {
+	k := &key{
+		person:    p,
+		lastname:  utils.Normalize(p.GetLastname()),
+		firstname: utils.Normalize(p.GetFirstname()),
+	}
+
+	k.lastCode = Soundex(k.lastname)
+	k.firstCode = Soundex(k.firstname)
+
+	if fields := strings.Fields(k.firstname); len(fields) > 0 {
+		k.firstToken = Soundex(fields[0])
+	}
+
+	return k
+}
+
+// unknown tells whether the names are missing, as the "?" of Geneweb.
+func (k *key) unknown() bool {
+	return k.lastCode == "" || k.firstToken == ""
+}
+
+// fullName returns the normalised names of a person, empty when they are
+// unknown.
+func fullName(p *tree.Person) string {
+	if p == nil {
+		return ""
+	}
+
+	firstname, lastname := utils.Normalize(p.GetFirstname()), utils.Normalize(p.GetLastname())
+	if firstname == "" || lastname == "" {
+		return ""
+	}
+
+	return firstname + "|" + lastname
+}
+
+func yearGap(a, b *api.Date) (int32, bool) {
+	yearA, okA := utils.DateYear(a)
+	yearB, okB := utils.DateYear(b)
+
+	if !okA || !okB {
+		return 0, false
+	}
+
+	if yearA > yearB {
+		return yearA - yearB, true
+	}
+
+	return yearB - yearA, true
+}
+
+type scorer struct {
+	opts    Options
+	score   float64
+	reasons []string
+}
+
+func (s *scorer) add(weight float64, reason string) {
+	s.score += weight
+	s.reasons = append(s.reasons, reason)
+}
+
+func (s *scorer) names(a, b *key) {
+	switch {
+	case a.lastname == b.lastname:
+		s.add(weightSameLastname, "same last name")
+	case a.lastCode == b.lastCode:
+		s.add(weightSimilarLastname, "similar last name")
+	}
+
+	switch {
+	case a.firstname == b.firstname:
+		s.add(weightSameFirstname, "same first name")
+	case a.firstCode == b.firstCode:
+		s.add(weightSimilarFirstname, "similar first name")
+	default:
+		s.add(weightSimilarGivenName, "similar first given name")
+	}
+}
+
+// dates returns false when the years are too far apart for the persons to be
+// the same.
+func (s *scorer) dates(a, b *tree.Person) bool {
+	if gap, ok := yearGap(a.GetBirthDate(), b.GetBirthDate()); ok {
+		switch {
+		case gap > s.opts.MaxYearGap:
+			return false
+		case gap == 0:
+			s.add(weightSameBirthYear, "same birth year")
+		case gap <= nearYears:
+			s.add(weightNearBirthYear, "near birth year")
+		case gap <= closeYears:
+			s.add(weightCloseBirthYear, "close birth year")
+		}
+	}
+
+	if gap, ok := yearGap(a.GetDeathDate(), b.GetDeathDate()); ok {
+		switch {
+		case gap > s.opts.MaxYearGap:
+			return false
+		case gap == 0:
+			s.add(weightSameDeathYear, "same death year")
+		case gap <= nearYears:
+			s.add(weightNearDeathYear, "near death year")
+		}
+	}
+
+	return true
+}
+
+// siblingDates tells whether the birth dates of two persons are known and the
+// same, and whether their dates contradict each other: different birth dates,
+// or one born after the death of the other.
+func siblingDates(a, b *tree.Person) (agree, contradict bool) {
+	cmp, ok := utils.CompareDates(a.GetBirthDate(), b.GetBirthDate())
+	if ok && cmp != 0 {
+		return false, true
+	}
+
+	for _, pair := range [][2]*tree.Person{{a, b}, {b, a}} {
+		if c, known := utils.CompareDates(pair[0].GetBirthDate(), pair[1].GetDeathDate()); known && c > 0 {
+			return false, true
+		}
+	}
+
+	return ok, false
+}
+
+// parents returns false when the persons are children of the same family
+// whose dates tell them apart, like a child named after a dead sibling. The
+// same parents only count when the birth dates of the children agree.
+func (s *scorer) parents(a, b *tree.Person) bool {
+	parentsA, parentsB := a.Parents(), b.Parents()
+	if parentsA == nil || parentsB == nil {
+		return true
+	}
+
+	if parentsA == parentsB {
+		agree, contradict := siblingDates(a, b)
+
+		switch {
+		case contradict:
+			return false
+		case agree:
+			s.add(weightSameParents, "same parents")
+		}
+
+		return true
+	}
+
+	fatherA, motherA := fullName(parentsA.Father()), fullName(parentsA.Mother())
+
+	if fatherA+motherA != "" && fatherA == fullName(parentsB.Father()) && motherA == fullName(parentsB.Mother()) {
+		s.add(weightSimilarParents, "parents with the same names")
+	}
+
+	return true
+}
+
+func (s *scorer) spouses(a, b *tree.Person) {
+	for _, spouseA := range a.Spouses() {
+		for _, spouseB := range b.Spouses() {
+			if name := fullName(spouseA); spouseA == spouseB || (name != "" && name == fullName(spouseB)) {
+				s.add(weightSameSpouse, "same spouse")
+
+				return
+			}
+		}
+	}
+}
+
+func compare(a, b *key, opts Options) (Candidate, bool) {
+	sexA, sexB := a.person.GetSex(), b.person.GetSex()
+	if sexA != sexB && sexA != api.Sex_UNKNOWN && sexB != api.Sex_UNKNOWN {
+		return Candidate{}, false
+	}
+
+	s := &scorer{opts: opts}
+
+	s.names(a, b)
+
+	if !s.dates(a.person, b.person) {
+		return Candidate{}, false
+	}
+
+	if !s.parents(a.person, b.person) {
+		return Candidate{}, false
+	}
+
+	s.spouses(a.person, b.person)
+
+	s.score = math.Round(math.Min(s.score, maxScore)*scoreRounding) / scoreRounding
+
+	if s.score < opts.Threshold {
+		return Candidate{}, false
+	}
+
+	return Candidate{
+		A:       a.person.GetIndex(),
+		B:       b.person.GetIndex(),
+		Score:   s.score,
+		Reasons: s.reasons,
+	}, true
+}
+
+// Find returns the pairs of persons of the tree which may be duplicates, the
+// most likely first. Only persons whose last names and first given names sound
+// the same are compared, so that large bases are not compared pairwise.
+func Find(t *tree.Tree, opts Options) []Candidate {
+	blocks := make(map[string][]*key)
+
+	for _, p := range t.Persons() {
+		k := newKey(p)
+		if k.unknown() {
+			continue
+		}
+
+		block := k.lastCode + "|" + k.firstToken
+		blocks[block] = append(blocks[block], k)
+	}
+
+	var candidates []Candidate
+
+	for _, block := range blocks {
+		for i, a := range block {
+			for _, b := range block[i+1:] {
+				if c, ok := compare(a, b, opts); ok {
+					candidates = append(candidates, c)
+				}
+			}
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Score != candidates[j].Score {
+			return candidates[i].Score > candidates[j].Score
+		}
+
+		if candidates[i].A != candidates[j].A {
+			return candidates[i].A < candidates[j].A
+		}
+
+		return candidates[i].B < candidates[j].B
+	})
+
+	return candidates
+}
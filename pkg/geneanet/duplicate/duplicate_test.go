@@ -0,0 +1,119 @@
+package duplicate
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/trois-six/geneparse/pkg/geneanet/api"
+	"github.com/trois-six/geneparse/pkg/geneanet/tree/treetest"
+)
+
+func TestSoundex(t *testing.T) {
+	for name, code := range map[string]string{
+		"Dupont":      "D150",
+		"Dupond":      "D150",
+		"Chevalier":   "S946",
+		"Schevallier": "S946",
+		"Philippe":    "F410",
+		"Bjørn":       "B765",
+		"Lévêque":     "L920",
+		"Li":          "L000",
+		"Иван":        "",
+		"?":           "",
+	} {
+		if got := Soundex(name); got != code {
+			t.Errorf("Soundex(%q) = %q, want %q", name, got, code)
+		}
+	}
+}
+
+func TestFind(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		build func(b *treetest.Builder)
+		want  [][2]int32
+	}{
+		{
+			name: "child named after a dead sibling",
+			build: func(b *treetest.Builder) {
+				father := b.Person(api.Sex_MALE, "Pierre", "Martin")
+				mother := b.Person(api.Sex_FEMALE, "Marie", "Durand")
+				first := b.Person(api.Sex_MALE, "Jean", "Martin")
+				first.BirthDate = treetest.Date(1880, 3, 2)     //nolint:gomnd
+				treetest.Died(first, treetest.Date(1881, 1, 5)) //nolint:gomnd
+				second := b.Person(api.Sex_MALE, "Jean", "Martin")
+				second.BirthDate = treetest.Date(1882, 6, 9) //nolint:gomnd
+				b.Family(father, mother, first, second)
+			},
+		},
+		{
+			name: "siblings born the same year",
+			build: func(b *treetest.Builder) {
+				father := b.Person(api.Sex_MALE, "Pierre", "Martin")
+				mother := b.Person(api.Sex_FEMALE, "Marie", "Durand")
+				first := b.Person(api.Sex_MALE, "Jean", "Martin")
+				first.BirthDate = treetest.Date(1880, 3, 2) //nolint:gomnd
+				second := b.Person(api.Sex_MALE, "Jean", "Martin")
+				second.BirthDate = treetest.Date(1880, 11, 20) //nolint:gomnd
+				b.Family(father, mother, first, second)
+			},
+		},
+		{
+			name: "same child entered twice",
+			build: func(b *treetest.Builder) {
+				father := b.Person(api.Sex_MALE, "Pierre", "Martin")
+				mother := b.Person(api.Sex_FEMALE, "Marie", "Durand")
+				first := b.Person(api.Sex_MALE, "Jean", "Martin")
+				first.BirthDate = treetest.Date(1880, 3, 2) //nolint:gomnd
+				second := b.Person(api.Sex_MALE, "Jean", "Martin")
+				second.BirthDate = treetest.Date(1880, 3, 2) //nolint:gomnd
+				b.Family(father, mother, first, second)
+			},
+			want: [][2]int32{{2, 3}},
+		},
+		{
+			name: "similar names only",
+			build: func(b *treetest.Builder) {
+				b.Person(api.Sex_MALE, "Louis", "Dupont").BirthDate = treetest.Date(1900, 1, 1) //nolint:gomnd
+				b.Person(api.Sex_FEMALE, "Anne", "Durand")
+				b.Person(api.Sex_MALE, "Louis", "Dupond").BirthDate = treetest.Date(1901, 0, 0) //nolint:gomnd
+			},
+		},
+		{
+			name: "same person with the same spouse",
+			build: func(b *treetest.Builder) {
+				louis := b.Person(api.Sex_MALE, "Louis", "Dupont")
+				louis.BirthDate = treetest.Date(1900, 1, 1) //nolint:gomnd
+				anne := b.Person(api.Sex_FEMALE, "Anne", "Durand")
+				b.Family(louis, anne)
+				other := b.Person(api.Sex_MALE, "Louis", "Dupont")
+				other.BirthDate = treetest.Date(1900, 0, 0) //nolint:gomnd
+				otherAnne := b.Person(api.Sex_FEMALE, "Anne", "Durand")
+				b.Family(other, otherAnne)
+			},
+			want: [][2]int32{{0, 2}, {1, 3}},
+		},
+		{
+			name: "different sexes",
+			build: func(b *treetest.Builder) {
+				b.Person(api.Sex_MALE, "Claude", "Dupont").BirthDate = treetest.Date(1900, 1, 1)   //nolint:gomnd
+				b.Person(api.Sex_FEMALE, "Claude", "Dupont").BirthDate = treetest.Date(1900, 1, 1) //nolint:gomnd
+			},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			b := &treetest.Builder{}
+			tc.build(b)
+
+			var got [][2]int32
+			for _, c := range Find(b.Tree(t), DefaultOptions()) {
+				got = append(got, [2]int32{c.A, c.B})
+			}
+
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Find() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package duplicate
+
+import (
+	"strings"
+
+	"github.com/trois-six/geneparse/pkg/geneanet/utils"
+)
+
+// soundexLength is the length of the phonetic codes, first letter included.
+const soundexLength = 4
+
+// soundexCodes are the digits of the French variant of Soundex: letters which
+// are not listed are vowels or silent letters.
+var soundexCodes = map[rune]byte{ // nolint:gochecknoglobals
+	'B': '1', 'P': '1',
+	'C': '2', 'K': '2', 'Q': '2',
+	'D': '3', 'T': '3',
+	'L': '4',
+	'M': '5', 'N': '5',
+	'R': '6',
+	'G': '7', 'J': '7',
+	'S': '8', 'X': '8', 'Z': '8',
+	'F': '9', 'V': '9',
+}
+
+// frenchSounds are rewritten before coding, so that spellings of the same
+// sound share a code.
+var frenchSounds = strings.NewReplacer( // nolint:gochecknoglobals
+	"SCH", "S",
+	"CH", "S",
+	"PH", "F",
+	"QU", "K",
+	"GU", "G",
+	"GN", "N",
+	"CK", "K",
+	"CE", "SE",
+	"CI", "SI",
+	"CY", "SI",
+	"Y", "I",
+)
+
+// Soundex returns the French phonetic code of a name, such that "Dupont" and
+// "Dupond", or "Chevalier" and "Schevallier", share the same code. It returns an
+// empty string when the name has no letter from A to Z.
+func Soundex(name string) string {
+	// Normalize leaves letters outside of A-Z, like "ø" or "œ", which are
+	// dropped so that the code is made of ASCII letters only.
+	s := strings.Map(func(r rune) rune {
+		if r < 'A' || r > 'Z' {
+			return -1
+		}
+
+		return r
+	}, strings.ToUpper(utils.Normalize(name)))
+	if s == "" {
+		return ""
+	}
+
+	s = frenchSounds.Replace(s)
+
+	// final consonants are mostly silent in French
+	if len(s) > 2 && strings.ContainsRune("DSTXZ", rune(s[len(s)-1])) {
+		s = s[:len(s)-1]
+	}
+
+	code := []byte{s[0]}
+	last := soundexCodes[rune(s[0])]
+
+	for _, r := range s[1:] {
+		digit, ok := soundexCodes[r]
+
+		switch {
+		case !ok && r != 'H' && r != 'W':
+			// vowels separate two identical consonants
+			last = 0
+		case ok && digit != last:
+			code = append(code, digit)
+			last = digit
+		}
+
+		if len(code) == soundexLength {
+			break
+		}
+	}
+
+	for len(code) < soundexLength {
+		code = append(code, '0')
+	}
+
+	return string(code)
+}
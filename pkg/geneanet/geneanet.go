@@ -3,18 +3,30 @@ package geneanet
 import (
 	"fmt"
 
+	"github.com/trois-six/geneparse/pkg/geneanet/consanguinity"
 	"github.com/trois-six/geneparse/pkg/geneanet/database"
 	"github.com/trois-six/geneparse/pkg/geneanet/gengedcom"
+	"github.com/trois-six/geneparse/pkg/geneanet/sosa"
+	"github.com/trois-six/geneparse/pkg/geneanet/tree"
 	"github.com/trois-six/geneparse/pkg/geneanet/utils"
 )
 
+// DefaultOutput is the gedcom file Parse creates unless SetOutput is called.
+const DefaultOutput = "output/test.ged"
+
 type Geneanet struct {
-	path string
+	path   string
+	output string
 
 	nbPersons uint32
 	sosa      uint32
 	rootSosa  uint32
 	timestamp int64
+
+	withSosa          bool
+	withConsanguinity bool
+	subtreeRoot       int32
+	subtree           *tree.SubtreeOptions
 }
 
 func New(path string) (*Geneanet, error) {
@@ -22,7 +34,78 @@ func New(path string) (*Geneanet, error) {
 		return nil, fmt.Errorf("%w: %s", utils.ErrDirDoesNotExist, path)
 	}
 
-	return &Geneanet{path: path}, nil
+	return &Geneanet{path: path, output: DefaultOutput}, nil
+}
+
+// SetOutput sets the path of the gedcom file Parse creates.
+func (g *Geneanet) SetOutput(output string) {
+	g.output = output
+}
+
+// SetSosa makes Parse add the Sosa numbers of the ancestors of the root person
+// to the gedcom file.
+func (g *Geneanet) SetSosa(withSosa bool) {
+	g.withSosa = withSosa
+}
+
+// SetConsanguinity makes Parse add the inbreeding coefficients of the inbred
+// persons to the gedcom file.
+func (g *Geneanet) SetConsanguinity(withConsanguinity bool) {
+	g.withConsanguinity = withConsanguinity
+}
+
+// configure loads the tree when the gedcom file needs more than the raw
+// databases, to set the Sosa numbers, the inbreeding coefficients and the
+// selection up. The whole base is then held in memory, while WriteDatabases
+// only streams it.
+func (g *Geneanet) configure(genGedcom *gengedcom.GenGedcom) error {
+	if !g.withSosa && !g.withConsanguinity && g.subtree == nil {
+		return nil
+	}
+
+	t, err := tree.Load(g.path)
+	if err != nil {
+		return fmt.Errorf("could not load tree: %w", err)
+	}
+
+	if g.withSosa {
+		var numbers sosa.Numbers
+
+		if numbers, err = sosa.Compute(t, int32(g.rootSosa), 0); err != nil {
+			return fmt.Errorf("could not compute sosa numbers: %w", err)
+		}
+
+		genGedcom.SetSosa(numbers)
+	}
+
+	if g.withConsanguinity {
+		var coefficients consanguinity.Coefficients
+
+		if coefficients, err = consanguinity.Compute(t); err != nil {
+			return fmt.Errorf("could not compute inbreeding coefficients: %w", err)
+		}
+
+		genGedcom.SetConsanguinity(coefficients)
+	}
+
+	if g.subtree != nil {
+		var selection *tree.Selection
+
+		if selection, err = t.Subtree(g.subtreeRoot, *g.subtree); err != nil {
+			return fmt.Errorf("could not select subtree: %w", err)
+		}
+
+		genGedcom.SetSelection(selection.Persons, selection.Families)
+	}
+
+	return nil
+}
+
+// SetSubtree makes Parse only export the branch of the tree around the person
+// of index root.
+func (g *Geneanet) SetSubtree(root int32, opts tree.SubtreeOptions) {
+	g.subtreeRoot = root
+	g.subtree = &opts
 }
 
 func (g *Geneanet) Parse() error {
@@ -39,17 +122,13 @@ func (g *Geneanet) Parse() error {
 	person := database.NewPerson(g.path)
 	family := database.NewFamily(g.path)
 
-	if err = database.PopulateDatabases([]database.Database{person, family}); err != nil {
-		return fmt.Errorf("databases populate failed: %w", err)
+	genGedcom := gengedcom.New(g.output)
+
+	if err = g.configure(&genGedcom); err != nil {
+		return err
 	}
 
-	genGedcom := gengedcom.New("test")
-	if err = genGedcom.Write("test",
-		person.GetPersons(),
-		family.GetFamilies(),
-		person.GetNotes(),
-		family.GetNotes(),
-	); err != nil {
+	if err = genGedcom.WriteDatabases(g.output, person, family); err != nil {
 		return fmt.Errorf("could not write gedcom: %w", err)
 	}
 
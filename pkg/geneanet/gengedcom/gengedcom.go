@@ -1,13 +1,18 @@
 package gengedcom
 
 import (
+	"bufio"
 	"fmt"
+	"io"
+	"math/big"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/trois-six/geneparse/pkg/geneanet/api"
+	"github.com/trois-six/geneparse/pkg/geneanet/database"
 	"github.com/trois-six/geneparse/pkg/geneanet/utils"
 	"github.com/elliotchance/gedcom"
 )
@@ -106,8 +111,51 @@ var mapMarriageTypeTagName = map[api.MarriageType]gedcom.Tag{ // nolint:gocheckn
 	api.MarriageType_RESIDENCE:                  gedcom.TagFromString("residence"),
 }
 
+// EventNameFromTag returns the event name whose GEDCOM tag is tag, the lowest
+// event name winning when several share the same tag.
+func EventNameFromTag(tag gedcom.Tag) (api.EventName, bool) {
+	var (
+		name  api.EventName
+		found bool
+	)
+
+	for n, t := range mapEventNameTagName {
+		if t.Is(tag) && (!found || n < name) {
+			name, found = n, true
+		}
+	}
+
+	return name, found
+}
+
+// MarriageTypeFromTag returns the marriage type whose GEDCOM tag is tag, the
+// lowest marriage type winning when several share the same tag.
+func MarriageTypeFromTag(tag gedcom.Tag) (api.MarriageType, bool) {
+	var (
+		marriageType api.MarriageType
+		found        bool
+	)
+
+	for m, t := range mapMarriageTypeTagName {
+		if t.Is(tag) && (!found || m < marriageType) {
+			marriageType, found = m, true
+		}
+	}
+
+	return marriageType, found
+}
+
+var (
+	tagSosa          = gedcom.TagFromString("_SOSA")    // nolint:gochecknoglobals
+	tagConsanguinity = gedcom.TagFromString("_CONSANG") // nolint:gochecknoglobals
+)
+
 type GenGedcom struct {
-	path string
+	path          string
+	sosa          map[int32][]*big.Int
+	consanguinity []float64
+	persons       map[int32]bool
+	families      map[int32]bool
 }
 
 func New(path string) GenGedcom {
@@ -116,6 +164,34 @@ func New(path string) GenGedcom {
 	}
 }
 
+// SetSosa makes the individuals carry their Sosa numbers, indexed by person
+// index, in _SOSA tags.
+func (g *GenGedcom) SetSosa(numbers map[int32][]*big.Int) {
+	g.sosa = numbers
+}
+
+// SetConsanguinity makes the inbred individuals carry their inbreeding
+// coefficient, indexed by person index, in _CONSANG tags.
+func (g *GenGedcom) SetConsanguinity(coefficients []float64) {
+	g.consanguinity = coefficients
+}
+
+// SetSelection restricts the output to the given persons and families, by
+// index. The links to persons and families left out are dropped, nil sets
+// meaning no restriction.
+func (g *GenGedcom) SetSelection(persons, families map[int32]bool) {
+	g.persons = persons
+	g.families = families
+}
+
+func (g *GenGedcom) keepPerson(i int32) bool {
+	return g.persons == nil || g.persons[i]
+}
+
+func (g *GenGedcom) keepFamily(i int32) bool {
+	return g.families == nil || g.families[i]
+}
+
 func getEmptyDocument(name string) *gedcom.Document {
 	currentTime := time.Now()
 	doc := gedcom.NewDocument()
@@ -138,7 +214,7 @@ func getEmptyDocument(name string) *gedcom.Document {
 		gedcom.NewNode(gedcom.TagDate, currentTime.Format("02 Jan 2006"), "",
 			gedcom.NewNode(gedcom.TagTime, currentTime.Format("15:04:05"), ""),
 		),
-		gedcom.NewNode(gedcom.TagFile, name+".ged", ""),
+		gedcom.NewNode(gedcom.TagFile, name, ""),
 	))
 
 	return doc
@@ -214,6 +290,16 @@ func getDate(date *api.Date) string {
 	return dateString
 }
 
+// DateString returns the GEDCOM value of a date, or its text when it has no
+// day, month nor year.
+func DateString(date *api.Date) string {
+	if date.GetDmy() == nil {
+		return date.GetText()
+	}
+
+	return getDate(date)
+}
+
 func getTitle(title *api.Title) gedcom.Node {
 	t := gedcom.NewNode(gedcom.TagTitle, title.GetTitle()+", "+title.GetFief(), "")
 
@@ -285,174 +371,277 @@ func getMarriageEvent(family *api.Family) []gedcom.Node {
 	return nodes
 }
 
-func createFullIndividualNodesAndFamilyNodes( //nolint:funlen,gocognit,gocyclo,cyclop
-	persons []*api.Person,
-	personsNotes [][]utils.NoteWithTag,
-	doc,
-	docFamilies *gedcom.Document) {
-	for i := 0; i < len(persons); i++ {
-		indiNode := doc.AddIndividual(utils.PointerStr("I", persons[i].GetIndex()+1))
-		indiNode.AddNode(getName(persons[i]))
-
-		if persons[i].Sex != nil {
-			if sex := getSex(persons[i].GetSex()); sex != "U" {
-				indiNode.SetSex(sex)
-			}
-		}
+func getNote(notes []utils.NoteWithTag) gedcom.Node {
+	noteNode := gedcom.NewNode(notes[0].GetTag(), notes[0].GetNote(), "")
 
-		if len(persons[i].Aliases) > 0 {
-			indiNode.AddName(strings.Join(persons[i].GetAliases(), ","))
-		}
+	for _, note := range notes[1:] {
+		noteNode.AddNode(gedcom.NewNode(note.GetTag(), note.GetNote(), ""))
+	}
 
-		if len(persons[i].Qualifiers) > 0 {
-			indiNode.AddNode(gedcom.NewNode(gedcom.TagNickname, strings.Join(persons[i].GetQualifiers(), ","), ""))
-		}
+	return noteNode
+}
 
-		if len(persons[i].SurnameAliases) > 0 {
-			indiNode.AddNode(gedcom.NewNode(gedcom.TagSurname, strings.Join(persons[i].GetSurnameAliases(), ","), ""))
-		}
+// individual returns a document holding the individual record of the person,
+// nil if it is not exported, and marks the families it refers to.
+func (g *GenGedcom) individual( //nolint:funlen,gocognit,gocyclo,cyclop
+	person *api.Person,
+	personNotes []utils.NoteWithTag,
+	families map[int32]bool) *gedcom.Document {
+	if !g.keepPerson(person.GetIndex()) {
+		return nil
+	}
 
-		if persons[i].Occupation != nil {
-			indiNode.AddNode(gedcom.NewNode(gedcom.TagOccupation, persons[i].GetOccupation(), ""))
-		}
+	doc := gedcom.NewDocument()
+	indiNode := doc.AddIndividual(utils.PointerStr("I", person.GetIndex()+1))
+	indiNode.AddNode(getName(person))
 
-		if persons[i].Psources != nil {
-			indiNode.AddNode(gedcom.NewNode(gedcom.TagSource, persons[i].GetPsources(), ""))
+	if person.Sex != nil {
+		if sex := getSex(person.GetSex()); sex != "U" {
+			indiNode.SetSex(sex)
 		}
+	}
 
-		for _, title := range persons[i].GetTitles() {
-			if t := getTitle(title); t != nil {
-				indiNode.AddNode(t)
-			}
-		}
+	if len(person.Aliases) > 0 {
+		indiNode.AddName(strings.Join(person.GetAliases(), ","))
+	}
 
-		if persons[i].Parents != nil {
-			familyID := utils.PointerStr("F", persons[i].GetParents())
-			indiNode.AddNode(gedcom.NewNode(gedcom.TagFamilyChild, "@"+familyID+"@", ""))
+	if len(person.Qualifiers) > 0 {
+		indiNode.AddNode(gedcom.NewNode(gedcom.TagNickname, strings.Join(person.GetQualifiers(), ","), ""))
+	}
 
-			if docFamilies.Families().ByPointer(familyID) == nil {
-				docFamilies.AddFamily(familyID)
-			}
+	if len(person.SurnameAliases) > 0 {
+		indiNode.AddNode(gedcom.NewNode(gedcom.TagSurname, strings.Join(person.GetSurnameAliases(), ","), ""))
+	}
+
+	if person.Occupation != nil {
+		indiNode.AddNode(gedcom.NewNode(gedcom.TagOccupation, person.GetOccupation(), ""))
+	}
+
+	if person.Psources != nil {
+		indiNode.AddNode(gedcom.NewNode(gedcom.TagSource, person.GetPsources(), ""))
+	}
+
+	for _, title := range person.GetTitles() {
+		if t := getTitle(title); t != nil {
+			indiNode.AddNode(t)
 		}
+	}
 
-		for _, family := range persons[i].GetFamilies() {
-			familyID := utils.PointerStr("F", family)
-			indiNode.AddNode(gedcom.NewNode(gedcom.TagFamilySpouse, "@"+familyID+"@", ""))
+	if person.Parents != nil && g.keepFamily(person.GetParents()) {
+		familyID := utils.PointerStr("F", person.GetParents())
+		indiNode.AddNode(gedcom.NewNode(gedcom.TagFamilyChild, "@"+familyID+"@", ""))
+		families[person.GetParents()] = true
+	}
 
-			if docFamilies.Families().ByPointer(familyID) == nil {
-				docFamilies.AddFamily(familyID)
-			}
+	for _, family := range person.GetFamilies() {
+		if !g.keepFamily(family) {
+			continue
 		}
 
-		for _, event := range persons[i].GetEvents() {
-			// events >= 50 are related to families, not individuals
-			if event.GetName() < api.EventName_EFAM_MARRIAGE {
-				if e := getEvent(event); e != nil && e.Tag().IsKnown() {
-					indiNode.AddNode(e)
-				}
+		familyID := utils.PointerStr("F", family)
+		indiNode.AddNode(gedcom.NewNode(gedcom.TagFamilySpouse, "@"+familyID+"@", ""))
+		families[family] = true
+	}
+
+	for _, event := range person.GetEvents() {
+		// events >= 50 are related to families, not individuals
+		if event.GetName() < api.EventName_EFAM_MARRIAGE {
+			if e := getEvent(event); e != nil && e.Tag().IsKnown() {
+				indiNode.AddNode(e)
 			}
 		}
+	}
 
-		if personsNotes[i] != nil {
-			noteNode := gedcom.NewNode(personsNotes[i][0].GetTag(), personsNotes[i][0].GetNote(), "")
+	for _, number := range g.sosa[person.GetIndex()] {
+		indiNode.AddNode(gedcom.NewNode(tagSosa, number.String(), ""))
+	}
 
-			if len(personsNotes[i]) > 0 {
-				for _, note := range personsNotes[i][1:] {
-					noteNode.AddNode(gedcom.NewNode(note.GetTag(), note.GetNote(), ""))
-				}
-			}
+	if i := int(person.GetIndex()); i < len(g.consanguinity) && g.consanguinity[i] > 0 {
+		indiNode.AddNode(gedcom.NewNode(tagConsanguinity, strconv.FormatFloat(g.consanguinity[i], 'f', -1, 64), ""))
+	}
 
-			indiNode.AddNode(noteNode)
-		}
+	if personNotes != nil {
+		indiNode.AddNode(getNote(personNotes))
 	}
+
+	return doc
 }
 
-func fillFamilies( //nolint:cyclop
-	families []*api.Family,
-	familiesNotes [][]utils.NoteWithTag,
-	doc *gedcom.Document) error {
-	for k, familyNode := range doc.Families() {
-		familyIdx, err := strconv.ParseInt(familyNode.Pointer()[1:], utils.ConstDecBase, 0)
-		if err != nil {
-			return fmt.Errorf("could not parse family ID: %w", err)
+// family returns a document holding the family record of the family, linked
+// to its members by pointer.
+func (g *GenGedcom) family(family *api.Family, familyNotes []utils.NoteWithTag) *gedcom.Document {
+	doc := gedcom.NewDocument()
+	familyNode := doc.AddFamily(utils.PointerStr("F", family.GetIndex()))
+
+	for _, event := range getMarriageEvent(family) {
+		if event.Tag().IsKnown() {
+			familyNode.AddNode(event)
 		}
+	}
 
-		family := families[familyIdx]
+	if family.Fsources != nil {
+		familyNode.AddNode(gedcom.NewSourceNode(family.GetFsources(), ""))
+	}
 
-		for _, event := range getMarriageEvent(family) {
-			if event.Tag().IsKnown() {
-				familyNode.AddNode(event)
-			}
-		}
+	if family.Father != nil && g.keepPerson(family.GetFather()) {
+		familyNode.SetHusbandPointer(utils.PointerStr("I", family.GetFather()+1))
+	}
 
-		if family.Fsources != nil {
-			familyNode.AddNode(gedcom.NewSourceNode(family.GetFsources(), ""))
-		}
+	if family.Mother != nil && g.keepPerson(family.GetMother()) {
+		familyNode.SetWifePointer(utils.PointerStr("I", family.GetMother()+1))
+	}
 
-		if family.Father != nil {
-			husbandID := utils.PointerStr("I", family.GetFather()+1)
-			familyNode.SetHusband(doc.Individuals().ByPointer(husbandID))
+	for _, child := range family.GetChildren() {
+		if !g.keepPerson(child) {
+			continue
 		}
 
-		if family.Mother != nil {
-			wifeID := utils.PointerStr("I", family.GetMother()+1)
-			familyNode.SetWife(doc.Individuals().ByPointer(wifeID))
-		}
+		// AddChild only needs the pointer of the individual, which is not
+		// looked up in a document holding all of them.
+		familyNode.AddChild(gedcom.NewDocument().AddIndividual(utils.PointerStr("I", child+1)))
+	}
 
-		for _, child := range family.GetChildren() {
-			childID := utils.PointerStr("I", child+1)
-			familyNode.AddChild(doc.Individuals().ByPointer(childID))
-		}
+	if familyNotes != nil {
+		familyNode.AddNode(getNote(familyNotes))
+	}
 
-		if familiesNotes[k] != nil {
-			noteNode := gedcom.NewNode(familiesNotes[k][0].GetTag(), familiesNotes[k][0].GetNote(), "")
+	return doc
+}
 
-			if len(familiesNotes[k]) > 0 {
-				for _, note := range familiesNotes[k][1:] {
-					noteNode.AddNode(gedcom.NewNode(note.GetTag(), note.GetNote(), ""))
-				}
-			}
+// encode writes the records of the document, if any.
+func encode(w io.Writer, doc *gedcom.Document) error {
+	if doc == nil {
+		return nil
+	}
 
-			familyNode.AddNode(noteNode)
-		}
+	if err := gedcom.NewEncoder(w, doc).Encode(); err != nil {
+		return fmt.Errorf("error writing gedcom file: %w", err)
 	}
 
 	return nil
 }
 
+// write creates the gedcom file of the given path, and its directory, records
+// writing its records one document at a time between the header and the
+// trailer. The records are not gathered in a single document, whose lookups and
+// additions scan all its records.
+func write(path string, records func(w io.Writer) error) error {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm|os.ModeDir); err != nil {
+		return fmt.Errorf("could not create gedcom file directory: %w", err)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("could not open gedcom file for writing: %w", err)
+	}
+
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+
+	if err = encode(w, getEmptyDocument(filepath.Base(path))); err != nil {
+		return err
+	}
+
+	if err = records(w); err != nil {
+		return err
+	}
+
+	trailer := gedcom.NewDocument()
+	trailer.AddNode(gedcom.NewNode(gedcom.TagTrailer, "", ""))
+
+	if err = encode(w, trailer); err != nil {
+		return err
+	}
+
+	if err = w.Flush(); err != nil {
+		return fmt.Errorf("error writing gedcom file: %w", err)
+	}
+
+	if err = f.Close(); err != nil {
+		return fmt.Errorf("error writing gedcom file: %w", err)
+	}
+
+	return nil
+}
+
+// Write creates the gedcom file of the given path with the persons and the
+// families.
 func (g *GenGedcom) Write(
-	name string,
+	path string,
 	persons []*api.Person,
 	families []*api.Family,
 	personsNotes, familiesNotes [][]utils.NoteWithTag) error {
-	doc := getEmptyDocument(name)
-	docFamilies := gedcom.NewDocument()
+	return write(path, func(w io.Writer) error {
+		referenced := map[int32]bool{}
 
-	createFullIndividualNodesAndFamilyNodes(persons, personsNotes, doc, docFamilies)
+		for i, person := range persons {
+			if err := encode(w, g.individual(person, personsNotes[i], referenced)); err != nil {
+				return err
+			}
+		}
 
-	for _, fam := range docFamilies.Nodes() {
-		doc.AddNode(fam)
-	}
+		for i, family := range families {
+			if referenced[family.GetIndex()] {
+				if err := encode(w, g.family(family, familiesNotes[i])); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	})
+}
 
-	if err := fillFamilies(families, familiesNotes, doc); err != nil {
-		return fmt.Errorf("failed to fill family nodes: %w", err)
+func readNote(db database.Database, i int32) ([]utils.NoteWithTag, error) {
+	note, err := db.ReadNote(int(i))
+	if err != nil || note == "" {
+		return nil, err
 	}
 
-	doc.AddNode(gedcom.NewNode(gedcom.TagTrailer, "", ""))
+	return utils.ExplodeNote(note), nil
+}
 
-	// log.Printf("%+v", doc)
+// WriteDatabases is like Write, but streams the persons and families from
+// their databases instead of loading them all in memory first.
+func (g *GenGedcom) WriteDatabases(path string, person *database.Person, family *database.Family) error {
+	for _, db := range []database.Database{person, family} {
+		if err := db.Open(); err != nil {
+			return fmt.Errorf("could not open database: %w", err)
+		}
 
-	f, err := os.Create("output/" + name + ".ged")
-	if err != nil {
-		return fmt.Errorf("could not open gedcom file for writing: %w", err)
+		defer db.Close()
 	}
 
-	defer f.Close()
+	return write(path, func(w io.Writer) error {
+		referenced := map[int32]bool{}
 
-	enc := gedcom.NewEncoder(f, doc)
-	if err = enc.Encode(); err != nil {
-		return fmt.Errorf("error writing gedcom file: %w", err)
-	}
+		if err := person.ForEachPerson(func(p *api.Person) error {
+			notes, err := readNote(person, p.GetIndex())
+			if err != nil {
+				return fmt.Errorf("could not read person note: %w", err)
+			}
 
-	return nil
+			return encode(w, g.individual(p, notes, referenced))
+		}); err != nil {
+			return fmt.Errorf("failed to create individual nodes: %w", err)
+		}
+
+		if err := family.ForEachFamily(func(f *api.Family) error {
+			if !referenced[f.GetIndex()] {
+				return nil
+			}
+
+			notes, err := readNote(family, f.GetIndex())
+			if err != nil {
+				return fmt.Errorf("could not read family note: %w", err)
+			}
+
+			return encode(w, g.family(f, notes))
+		}); err != nil {
+			return fmt.Errorf("failed to fill family nodes: %w", err)
+		}
+
+		return nil
+	})
 }
@@ -0,0 +1,130 @@
+package impgedcom
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/trois-six/geneparse/pkg/geneanet/api"
+	"github.com/trois-six/geneparse/pkg/geneanet/utils"
+	"google.golang.org/protobuf/proto"
+)
+
+// mapDatePrefixPrecision is the reverse of the precisions written by gengedcom.
+var mapDatePrefixPrecision = map[string]api.Precision{ // nolint:gochecknoglobals
+	"ABT": api.Precision_ABOUT,
+	"CAL": api.Precision_ABOUT,
+	"EST": api.Precision_MAYBE,
+	"BEF": api.Precision_BEFORE,
+	"AFT": api.Precision_AFTER,
+}
+
+var mapCalendarEscape = map[string]api.Calendar{ // nolint:gochecknoglobals
+	"@#DGREGORIAN@": api.Calendar_GREGORIAN,
+	"@#DJULIAN@":    api.Calendar_JULIAN,
+	"@#DFRENCH R@":  api.Calendar_FRENCH,
+	"@#DHEBREW@":    api.Calendar_HEBREW,
+}
+
+var mapMonthNumber = map[string]int32{ // nolint:gochecknoglobals
+	"JAN": 1, "FEB": 2, "MAR": 3, "APR": 4, "MAY": 5, "JUN": 6,
+	"JUL": 7, "AUG": 8, "SEP": 9, "OCT": 10, "NOV": 11, "DEC": 12,
+}
+
+func getDmy(s string) *api.Dmy {
+	fields := strings.Fields(s)
+	if len(fields) == 0 || len(fields) > 3 {
+		return nil
+	}
+
+	var day, month, year int64
+
+	year, err := strconv.ParseInt(fields[len(fields)-1], utils.ConstDecBase, 32)
+	if err != nil {
+		return nil
+	}
+
+	if len(fields) >= 2 {
+		m, ok := mapMonthNumber[strings.ToUpper(fields[len(fields)-2])]
+		if !ok {
+			return nil
+		}
+
+		month = int64(m)
+	}
+
+	if len(fields) == 3 {
+		if day, err = strconv.ParseInt(fields[0], utils.ConstDecBase, 32); err != nil {
+			return nil
+		}
+	}
+
+	return &api.Dmy{
+		Day:   proto.Int32(int32(day)),
+		Month: proto.Int32(int32(month)),
+		Year:  proto.Int32(int32(year)),
+		Delta: proto.Int32(0),
+	}
+}
+
+// getDate parses a GEDCOM date value, falling back to a text date when it is
+// not understood.
+func getDate(value string) *api.Date {
+	s := strings.TrimSpace(value)
+	date := &api.Date{}
+
+	for escape, cal := range mapCalendarEscape {
+		if strings.HasPrefix(s, escape) {
+			date.Cal = cal.Enum()
+			s = strings.TrimSpace(strings.TrimPrefix(s, escape))
+
+			break
+		}
+	}
+
+	prec := api.Precision_SURE
+	first, rest := s, ""
+
+	if i := strings.Index(s, " "); i >= 0 {
+		first, rest = s[:i], s[i+1:]
+	}
+
+	var dmy, dmy2 *api.Dmy
+
+	first = strings.ToUpper(first)
+
+	if p, ok := mapDatePrefixPrecision[first]; ok {
+		prec = p
+		dmy = getDmy(rest)
+	} else if first == "FROM" || first == "BET" {
+		prec = api.Precision_ORYEAR
+		sep := " TO "
+
+		if first == "BET" {
+			prec = api.Precision_YEARINT
+			sep = " AND "
+		}
+
+		parts := strings.SplitN(strings.ToUpper(rest), sep, 2)
+		dmy = getDmy(parts[0])
+
+		if len(parts) == 2 {
+			if dmy2 = getDmy(parts[1]); dmy2 == nil {
+				dmy = nil
+			}
+		}
+	} else {
+		dmy = getDmy(s)
+	}
+
+	if dmy == nil {
+		date.Text = proto.String(strings.TrimSpace(value))
+
+		return date
+	}
+
+	date.Prec = prec.Enum()
+	date.Dmy = dmy
+	date.Dmy2 = dmy2
+
+	return date
+}
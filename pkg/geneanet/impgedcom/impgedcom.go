@@ -0,0 +1,512 @@
+package impgedcom
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/elliotchance/gedcom"
+	"github.com/trois-six/geneparse/pkg/geneanet/api"
+	"github.com/trois-six/geneparse/pkg/geneanet/database"
+	"github.com/trois-six/geneparse/pkg/geneanet/gengedcom"
+	"google.golang.org/protobuf/proto"
+)
+
+// unknownName is the name Geneweb gives to persons it knows nothing about.
+const unknownName = "?"
+
+var nameRegexp = regexp.MustCompile(`^\s*(?:"([^"]*)")?\s*([^/]*?)\s*(?:/([^/]*)/)?\s*$`) // nolint:gochecknoglobals
+
+type ImpGedcom struct {
+	path          string
+	doc           *gedcom.Document
+	persons       []*api.Person
+	families      []*api.Family
+	personsNotes  []string
+	familiesNotes []string
+	personsIdx    map[string]int32
+	familiesIdx   map[string]int32
+	occ           map[string]int32
+}
+
+// New initialize an ImpGedcom writing Geneanet bases to path.
+func New(path string) ImpGedcom {
+	return ImpGedcom{
+		path: path,
+	}
+}
+
+func pointer(value string) string {
+	return strings.Trim(value, "@")
+}
+
+func splitList(value string) []string {
+	var list []string
+
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+
+	return list
+}
+
+func nonEmpty(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+
+	return value
+}
+
+// getText returns the value of a node followed by its CONT and CONC lines.
+func getText(node gedcom.Node) string {
+	text := node.Value()
+
+	for _, child := range node.Nodes() {
+		switch {
+		case child.Tag().Is(gedcom.TagContinued):
+			text += "\n" + child.Value()
+		case child.Tag().Is(gedcom.TagConcatenation):
+			text += child.Value()
+		}
+	}
+
+	return text
+}
+
+// resolve returns the record a pointer value refers to, or the node itself.
+func (i *ImpGedcom) resolve(node gedcom.Node) gedcom.Node {
+	if v := node.Value(); strings.HasPrefix(v, "@") && strings.HasSuffix(v, "@") {
+		if record := i.doc.NodeByPointer(pointer(v)); record != nil {
+			return record
+		}
+	}
+
+	return node
+}
+
+func (i *ImpGedcom) getNote(node gedcom.Node) string {
+	var notes []string
+
+	for _, n := range gedcom.NodesWithTag(node, gedcom.TagNote) {
+		notes = append(notes, getText(i.resolve(n)))
+	}
+
+	return strings.Join(notes, "\n")
+}
+
+// getSource returns the first source of the node, if any.
+func (i *ImpGedcom) getSource(node gedcom.Node) *string {
+	n := gedcom.First(gedcom.NodesWithTag(node, gedcom.TagSource))
+	if gedcom.IsNil(n) {
+		return nil
+	}
+
+	return i.source(n)
+}
+
+// source returns the title of the source record a SOUR node points to, or the
+// text of the SOUR node itself.
+func (i *ImpGedcom) source(n gedcom.Node) *string {
+	source := i.resolve(n)
+	if source != n {
+		if title := gedcom.First(gedcom.NodesWithTag(source, gedcom.TagTitle)); !gedcom.IsNil(title) {
+			return proto.String(getText(title))
+		}
+	}
+
+	return proto.String(getText(source))
+}
+
+func (i *ImpGedcom) getEvent(node gedcom.Node, name api.EventName) *api.Event {
+	event := &api.Event{Name: name.Enum()}
+
+	if date := gedcom.First(gedcom.NodesWithTag(node, gedcom.TagDate)); !gedcom.IsNil(date) {
+		event.Date = getDate(date.Value())
+	}
+
+	if place := gedcom.First(gedcom.NodesWithTag(node, gedcom.TagPlace)); !gedcom.IsNil(place) {
+		event.Place = proto.String(place.Value())
+	}
+
+	if note := i.getNote(node); note != "" {
+		event.Note = proto.String(note)
+	}
+
+	event.Src = i.getSource(node)
+
+	return event
+}
+
+func (i *ImpGedcom) nextOcc(firstname, lastname string) int32 {
+	key := strings.ToLower(firstname) + " " + strings.ToLower(lastname)
+	occ := i.occ[key]
+	i.occ[key]++
+
+	return occ
+}
+
+func (i *ImpGedcom) setName(person *api.Person, node *gedcom.NameNode) {
+	m := nameRegexp.FindStringSubmatch(node.Value())
+	if m == nil {
+		m = []string{"", "", node.Value(), ""}
+	}
+
+	firstname, lastname := m[2], m[3]
+
+	if given := gedcom.First(gedcom.NodesWithTag(node, gedcom.TagGivenName)); !gedcom.IsNil(given) {
+		firstname = given.Value()
+	}
+
+	if surname := gedcom.First(gedcom.NodesWithTag(node, gedcom.TagSurname)); !gedcom.IsNil(surname) {
+		lastname = surname.Value()
+	}
+
+	person.Firstname = proto.String(nonEmpty(strings.TrimSpace(firstname), unknownName))
+	person.Lastname = proto.String(nonEmpty(strings.TrimSpace(lastname), unknownName))
+	person.FirstnameAliases = splitList(m[1])
+}
+
+func getTitle(node gedcom.Node) *api.Title {
+	title := &api.Title{TitleType: api.TitleType_TITLE_NONE.Enum()}
+
+	parts := strings.SplitN(node.Value(), ", ", 2)
+	title.Title = proto.String(parts[0])
+
+	if len(parts) == 2 {
+		title.Fief = proto.String(parts[1])
+	}
+
+	if date := gedcom.First(gedcom.NodesWithTag(node, gedcom.TagDate)); !gedcom.IsNil(date) {
+		value := strings.ToUpper(date.Value())
+		begin, end := value, ""
+
+		if i := strings.Index(value, "TO "); i >= 0 {
+			begin, end = value[:i], value[i+len("TO "):]
+		}
+
+		if begin = strings.TrimSpace(strings.TrimPrefix(begin, "FROM ")); begin != "" {
+			title.DateBegin = getDate(begin)
+		}
+
+		if end = strings.TrimSpace(end); end != "" {
+			title.DateEnd = getDate(end)
+		}
+	}
+
+	return title
+}
+
+// setVitalEvent fills the dedicated birth, baptism, death and burial fields of
+// a person from its event.
+func setVitalEvent(person *api.Person, event *api.Event) {
+	switch event.GetName() {
+	case api.EventName_EPERS_BIRTH:
+		person.BirthDate, person.BirthPlace, person.BirthSrc = event.Date, event.Place, event.Src
+	case api.EventName_EPERS_BAPTISM:
+		person.BaptismDate, person.BaptismPlace, person.BaptismSrc = event.Date, event.Place, event.Src
+	case api.EventName_EPERS_DEATH:
+		person.DeathDate, person.DeathPlace, person.DeathSrc = event.Date, event.Place, event.Src
+	case api.EventName_EPERS_BURIAL, api.EventName_EPERS_CREMATION:
+		person.BurialDate, person.BurialPlace, person.BurialSrc = event.Date, event.Place, event.Src
+	default:
+	}
+}
+
+func getDeathType(person *api.Person) api.DeathType {
+	switch {
+	case person.DeathDate != nil:
+		return api.DeathType_DEAD
+	case person.DeathPlace != nil || person.BurialDate != nil || person.BurialPlace != nil:
+		return api.DeathType_DEAD_DONT_KNOW_WHEN
+	default:
+		for _, event := range person.GetEvents() {
+			if event.GetName() == api.EventName_EPERS_DEATH {
+				return api.DeathType_DEAD_DONT_KNOW_WHEN
+			}
+		}
+
+		return api.DeathType_DONT_KNOW_IF_DEAD
+	}
+}
+
+func getSex(node *gedcom.IndividualNode) api.Sex {
+	switch sex := node.Sex(); {
+	case sex.IsMale():
+		return api.Sex_MALE
+	case sex.IsFemale():
+		return api.Sex_FEMALE
+	default:
+		return api.Sex_UNKNOWN
+	}
+}
+
+func (i *ImpGedcom) readIndividual(k int, node *gedcom.IndividualNode) { //nolint:funlen,gocognit,gocyclo,cyclop
+	person := &api.Person{
+		Index: proto.Int32(int32(k)),
+		Sex:   getSex(node).Enum(),
+	}
+
+	names := node.Names()
+	if len(names) > 0 {
+		i.setName(person, names[0])
+
+		for _, alias := range names[1:] {
+			person.Aliases = append(person.Aliases, splitList(alias.Value())...)
+		}
+	} else {
+		person.Firstname = proto.String(unknownName)
+		person.Lastname = proto.String(unknownName)
+	}
+
+	person.Occ = proto.Int32(i.nextOcc(person.GetFirstname(), person.GetLastname()))
+
+	for _, child := range node.Nodes() {
+		tag := child.Tag()
+
+		switch {
+		case tag.Is(gedcom.TagNickname):
+			person.Qualifiers = append(person.Qualifiers, splitList(child.Value())...)
+		case tag.Is(gedcom.TagSurname):
+			person.SurnameAliases = append(person.SurnameAliases, splitList(child.Value())...)
+		case tag.Is(gedcom.TagSource):
+			if person.Psources == nil {
+				person.Psources = i.source(child)
+			}
+		case tag.Is(gedcom.TagTitle):
+			person.Titles = append(person.Titles, getTitle(child))
+		case tag.Is(gedcom.TagFamilyChild):
+			if person.Parents == nil {
+				if f, ok := i.familiesIdx[pointer(child.Value())]; ok {
+					person.Parents = proto.Int32(f)
+				}
+			}
+		case tag.Is(gedcom.TagFamilySpouse):
+			if f, ok := i.familiesIdx[pointer(child.Value())]; ok {
+				person.Families = append(person.Families, f)
+			}
+		case tag.Is(gedcom.TagOccupation) && person.Occupation == nil && len(child.Nodes()) == 0:
+			person.Occupation = proto.String(child.Value())
+		case tag.Is(gedcom.TagChristening):
+			event := i.getEvent(child, api.EventName_EPERS_BAPTISM)
+			person.Events = append(person.Events, event)
+			setVitalEvent(person, event)
+		default:
+			if name, ok := gengedcom.EventNameFromTag(tag); ok && name < api.EventName_EFAM_MARRIAGE {
+				event := i.getEvent(child, name)
+				person.Events = append(person.Events, event)
+				setVitalEvent(person, event)
+			}
+		}
+	}
+
+	person.DeathType = getDeathType(person).Enum()
+
+	i.persons = append(i.persons, person)
+	i.personsNotes = append(i.personsNotes, i.getNote(node))
+}
+
+// newUnknownPerson adds the placeholder Geneweb uses for a missing spouse.
+func (i *ImpGedcom) newUnknownPerson(sex api.Sex, family int32) int32 {
+	k := int32(len(i.persons))
+
+	i.persons = append(i.persons, &api.Person{
+		Index:     proto.Int32(k),
+		Sex:       sex.Enum(),
+		Lastname:  proto.String(unknownName),
+		Firstname: proto.String(unknownName),
+		Occ:       proto.Int32(i.nextOcc(unknownName, unknownName)),
+		DeathType: api.DeathType_DONT_KNOW_IF_DEAD.Enum(),
+		Families:  []int32{family},
+	})
+	i.personsNotes = append(i.personsNotes, "")
+
+	return k
+}
+
+func (i *ImpGedcom) getSpouse(node gedcom.Node, tag gedcom.Tag, sex api.Sex, family int32) int32 {
+	if n := gedcom.First(gedcom.NodesWithTag(node, tag)); !gedcom.IsNil(n) {
+		if p, ok := i.personsIdx[pointer(n.Value())]; ok {
+			return p
+		}
+	}
+
+	return i.newUnknownPerson(sex, family)
+}
+
+func (i *ImpGedcom) readFamily(k int, node *gedcom.FamilyNode) { //nolint:funlen,cyclop
+	family := &api.Family{
+		Index:        proto.Int32(int32(k)),
+		MarriageType: api.MarriageType_NOT_MARRIED.Enum(),
+		DivorceType:  api.DivorceType_NOT_DIVORCED.Enum(),
+	}
+
+	family.Father = proto.Int32(i.getSpouse(node, gedcom.TagHusband, api.Sex_MALE, int32(k)))
+	family.Mother = proto.Int32(i.getSpouse(node, gedcom.TagWife, api.Sex_FEMALE, int32(k)))
+
+	var (
+		events        []*api.Event
+		marriageFound bool
+	)
+
+	for _, child := range node.Nodes() {
+		tag := child.Tag()
+
+		switch {
+		case tag.Is(gedcom.TagChild):
+			if p, ok := i.personsIdx[pointer(child.Value())]; ok {
+				family.Children = append(family.Children, p)
+			}
+		case tag.Is(gedcom.TagSource):
+			if family.Fsources == nil {
+				family.Fsources = i.source(child)
+			}
+		case tag.Is(gedcom.TagDivorce):
+			event := i.getEvent(child, api.EventName_EFAM_DIVORCE)
+			family.DivorceType = api.DivorceType_DIVORCED.Enum()
+			family.DivorceDate = event.Date
+			events = append(events, event)
+		default:
+			if marriageType, ok := gengedcom.MarriageTypeFromTag(tag); ok && !marriageFound {
+				event := i.getEvent(child, api.EventName_EFAM_MARRIAGE)
+				family.MarriageType = marriageType.Enum()
+				family.MarriageDate, family.MarriagePlace, family.MarriageSrc = event.Date, event.Place, event.Src
+				marriageFound = true
+			}
+
+			if name, ok := gengedcom.EventNameFromTag(tag); ok && name >= api.EventName_EFAM_MARRIAGE {
+				events = append(events, i.getEvent(child, name))
+			}
+		}
+	}
+
+	// Family events are also recorded on both spouses, as Geneweb does.
+	for _, event := range events {
+		father, mother := i.persons[family.GetFather()], i.persons[family.GetMother()]
+
+		fatherEvent := proto.Clone(event).(*api.Event)
+		fatherEvent.IndexSpouse = proto.Int32(family.GetMother())
+		father.Events = append(father.Events, fatherEvent)
+
+		motherEvent := proto.Clone(event).(*api.Event)
+		motherEvent.IndexSpouse = proto.Int32(family.GetFather())
+		mother.Events = append(mother.Events, motherEvent)
+	}
+
+	i.families = append(i.families, family)
+	i.familiesNotes = append(i.familiesNotes, i.getNote(node))
+}
+
+func containsIndex(list []int32, v int32) bool {
+	for _, e := range list {
+		if e == v {
+			return true
+		}
+	}
+
+	return false
+}
+
+// link makes persons and families agree on parents, spouses and children,
+// whichever side of the GEDCOM declared the link.
+func (i *ImpGedcom) link() {
+	for _, family := range i.families {
+		for _, spouse := range []int32{family.GetFather(), family.GetMother()} {
+			if p := i.persons[spouse]; !containsIndex(p.Families, family.GetIndex()) {
+				p.Families = append(p.Families, family.GetIndex())
+			}
+		}
+
+		for _, child := range family.GetChildren() {
+			if p := i.persons[child]; p.Parents == nil {
+				p.Parents = proto.Int32(family.GetIndex())
+			}
+		}
+	}
+
+	for _, person := range i.persons {
+		if person.Parents == nil {
+			continue
+		}
+
+		if family := i.families[person.GetParents()]; !containsIndex(family.Children, person.GetIndex()) {
+			family.Children = append(family.Children, person.GetIndex())
+		}
+	}
+}
+
+// Read converts a GEDCOM file into Geneanet persons and families.
+func (i *ImpGedcom) Read(file string) error {
+	doc, err := gedcom.NewDocumentFromGEDCOMFile(file)
+	if err != nil {
+		return fmt.Errorf("could not read gedcom file: %w", err)
+	}
+
+	i.doc = doc
+	i.persons, i.families = nil, nil
+	i.personsNotes, i.familiesNotes = nil, nil
+	i.personsIdx = map[string]int32{}
+	i.familiesIdx = map[string]int32{}
+	i.occ = map[string]int32{}
+
+	individuals := doc.Individuals()
+	families := doc.Families()
+
+	for k, individual := range individuals {
+		i.personsIdx[individual.Pointer()] = int32(k)
+	}
+
+	for k, family := range families {
+		i.familiesIdx[family.Pointer()] = int32(k)
+	}
+
+	for k, individual := range individuals {
+		i.readIndividual(k, individual)
+	}
+
+	for k, family := range families {
+		i.readFamily(k, family)
+	}
+
+	i.link()
+
+	return nil
+}
+
+// Write writes the persons and families read from the GEDCOM file as
+// Geneanet bases.
+func (i *ImpGedcom) Write() error {
+	person := database.NewPerson(i.path)
+	person.SetPersons(i.persons)
+	person.SetNotes(i.personsNotes)
+
+	family := database.NewFamily(i.path)
+	family.SetFamilies(i.families)
+	family.SetNotes(i.familiesNotes)
+
+	if err := database.WriteDatabases([]database.Database{person, family}); err != nil {
+		return fmt.Errorf("databases write failed: %w", err)
+	}
+
+	info := &database.BaseInfo{
+		NbPersons: uint32(len(i.persons)),
+		Timestamp: time.Now().Unix(),
+	}
+
+	if err := database.WriteInfoBase(i.path, info); err != nil {
+		return fmt.Errorf("could not write base info: %w", err)
+	}
+
+	return nil
+}
+
+func (i *ImpGedcom) GetPersons() []*api.Person {
+	return i.persons
+}
+
+func (i *ImpGedcom) GetFamilies() []*api.Family {
+	return i.families
+}
@@ -0,0 +1,86 @@
+package merge
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/trois-six/geneparse/pkg/geneanet/utils"
+)
+
+// Ref is a person of one of the merged bases: Base is the position of its base
+// among the merged ones, from 1, and Index its index in that base.
+type Ref struct {
+	Base  int
+	Index int32
+}
+
+func (r Ref) String() string {
+	return fmt.Sprintf("%d:%d", r.Base, r.Index)
+}
+
+// Link tells that two persons of the merged bases are the same.
+type Link struct {
+	A, B Ref
+}
+
+func parseRef(s string) (Ref, error) {
+	parts := strings.SplitN(s, ":", 2) //nolint:gomnd
+	if len(parts) != 2 {               //nolint:gomnd
+		return Ref{}, fmt.Errorf("%w: %q is not <base>:<index>", utils.ErrFileMalFormatted, s)
+	}
+
+	base, errBase := strconv.Atoi(parts[0])
+	if errBase != nil {
+		return Ref{}, fmt.Errorf("%w: base of %q", utils.ErrFileMalFormatted, s)
+	}
+
+	index, errIndex := strconv.ParseInt(parts[1], utils.ConstDecBase, 32) //nolint:gomnd
+	if errIndex != nil {
+		return Ref{}, fmt.Errorf("%w: index of %q", utils.ErrFileMalFormatted, s)
+	}
+
+	return Ref{Base: base, Index: int32(index)}, nil
+}
+
+// ReadLinks reads a mapping file linking identical persons. Each line holds two
+// persons as <base>:<index>, separated by spaces, such as "1:12 2:40" for the
+// person 12 of the first base and the person 40 of the second one. Empty lines
+// and lines starting with # are ignored.
+func ReadLinks(r io.Reader) ([]Link, error) {
+	var links []Link
+
+	scanner := bufio.NewScanner(r)
+
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Fields(text)
+		if len(fields) != 2 { //nolint:gomnd
+			return nil, fmt.Errorf("%w: line %d: expected two persons", utils.ErrFileMalFormatted, line)
+		}
+
+		a, err := parseRef(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		b, err := parseRef(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		links = append(links, Link{A: a, B: b})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf(utils.ErrRead, err)
+	}
+
+	return links, nil
+}
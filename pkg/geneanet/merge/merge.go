@@ -0,0 +1,424 @@
+package merge
+
+import (
+	"fmt"
+
+	"github.com/trois-six/geneparse/pkg/geneanet/api"
+	"github.com/trois-six/geneparse/pkg/geneanet/database"
+	"github.com/trois-six/geneparse/pkg/geneanet/duplicate"
+	"github.com/trois-six/geneparse/pkg/geneanet/tree"
+	"github.com/trois-six/geneparse/pkg/geneanet/utils"
+)
+
+// Options tells which persons of the merged bases are the same: the ones
+// linked together, and, when Duplicates is set, the duplicates of different
+// bases found with the duplicate options.
+type Options struct {
+	Links      []Link
+	Duplicates *duplicate.Options
+}
+
+// Base is the combination of merged bases, its persons and families being
+// indexed by their position.
+type Base struct {
+	Persons       []*api.Person
+	Families      []*api.Family
+	PersonsNotes  []string
+	FamiliesNotes []string
+	// Linked is the number of persons merged into another one.
+	Linked int
+}
+
+// Notes returns the notes of the records split in their lines, as expected
+// by gengedcom.
+func Notes(notes []string) [][]utils.NoteWithTag {
+	exploded := make([][]utils.NoteWithTag, len(notes))
+
+	for k, note := range notes {
+		if note != "" {
+			exploded[k] = utils.ExplodeNote(note)
+		}
+	}
+
+	return exploded
+}
+
+// merger holds the records of all the bases, their indexes shifted so that
+// the records of a base follow the ones of the previous bases.
+type merger struct {
+	Base
+	personOffsets []int32
+	familyOffsets []int32
+	// person and family map every record to the one it is merged into.
+	person, family []int32
+}
+
+func (m *merger) load(paths []string) error {
+	for _, path := range paths {
+		person := database.NewPerson(path)
+		family := database.NewFamily(path)
+
+		if err := database.PopulateDatabases([]database.Database{person, family}); err != nil {
+			return fmt.Errorf("databases populate failed for %s: %w", path, err)
+		}
+
+		personOffset, familyOffset := int32(len(m.Persons)), int32(len(m.Families))
+		m.personOffsets = append(m.personOffsets, personOffset)
+		m.familyOffsets = append(m.familyOffsets, familyOffset)
+
+		persons, families := person.GetPersons(), family.GetFamilies()
+		shift(persons, families, func(i int32) int32 { return i + personOffset },
+			func(i int32) int32 { return i + familyOffset })
+
+		m.Persons = append(m.Persons, persons...)
+		m.Families = append(m.Families, families...)
+		m.PersonsNotes = append(m.PersonsNotes, padNotes(person.GetRawNotes(), len(persons))...)
+		m.FamiliesNotes = append(m.FamiliesNotes, padNotes(family.GetRawNotes(), len(families))...)
+	}
+
+	m.person = identity(len(m.Persons))
+	m.family = identity(len(m.Families))
+
+	return nil
+}
+
+func padNotes(notes []string, n int) []string {
+	padded := make([]string, n)
+	copy(padded, notes)
+
+	return padded
+}
+
+func identity(n int) []int32 {
+	indexes := make([]int32, n)
+	for k := range indexes {
+		indexes[k] = int32(k)
+	}
+
+	return indexes
+}
+
+func setIndex(p **int32, remap func(int32) int32) {
+	if *p != nil {
+		i := remap(**p)
+		*p = &i
+	}
+}
+
+func remapAll(indexes []int32, remap func(int32) int32) {
+	for k, i := range indexes {
+		indexes[k] = remap(i)
+	}
+}
+
+// shift rewrites the indexes of the persons and the families, and all the
+// references they hold to each other.
+func shift(persons []*api.Person, families []*api.Family, person, family func(int32) int32) {
+	for _, p := range persons {
+		setIndex(&p.Index, person)
+		setIndex(&p.Parents, family)
+		remapAll(p.Families, family)
+		remapAll(p.Related, person)
+
+		for _, r := range p.Rparents {
+			setIndex(&r.Father, person)
+			setIndex(&r.Mother, person)
+		}
+
+		for _, event := range p.Events {
+			setIndex(&event.IndexSpouse, person)
+
+			for _, witness := range event.Witnesses {
+				setIndex(&witness.Witness, person)
+			}
+		}
+	}
+
+	for _, f := range families {
+		setIndex(&f.Index, family)
+		setIndex(&f.Father, person)
+		setIndex(&f.Mother, person)
+		remapAll(f.Children, person)
+		remapAll(f.Witnesses, person)
+	}
+}
+
+func find(parents []int32, i int32) int32 {
+	for parents[i] != i {
+		parents[i] = parents[parents[i]]
+		i = parents[i]
+	}
+
+	return i
+}
+
+// union merges the sets of a and b, the smallest index representing them.
+func union(parents []int32, a, b int32) {
+	a, b = find(parents, a), find(parents, b)
+
+	switch {
+	case a < b:
+		parents[b] = a
+	case b < a:
+		parents[a] = b
+	}
+}
+
+func (m *merger) ref(r Ref) (int32, error) {
+	if r.Base < 1 || r.Base > len(m.personOffsets) {
+		return 0, fmt.Errorf("%w: base of %s", utils.ErrIndexOutOfRange, r)
+	}
+
+	end := int32(len(m.Persons))
+	if r.Base < len(m.personOffsets) {
+		end = m.personOffsets[r.Base]
+	}
+
+	i := m.personOffsets[r.Base-1] + r.Index
+	if r.Index < 0 || i >= end {
+		return 0, fmt.Errorf("%w: person %s", utils.ErrIndexOutOfRange, r)
+	}
+
+	return i, nil
+}
+
+// base returns the position, from 0, of the base the record of index i comes
+// from, given the offsets of the records of the bases.
+func base(offsets []int32, i int32) int {
+	k := 0
+	for k+1 < len(offsets) && offsets[k+1] <= i {
+		k++
+	}
+
+	return k
+}
+
+// link merges the persons linked together and the duplicates of different
+// bases.
+func (m *merger) link(opts Options) error {
+	for _, l := range opts.Links {
+		a, err := m.ref(l.A)
+		if err != nil {
+			return err
+		}
+
+		b, err := m.ref(l.B)
+		if err != nil {
+			return err
+		}
+
+		union(m.person, a, b)
+	}
+
+	if opts.Duplicates == nil {
+		return nil
+	}
+
+	t, err := tree.New(nil, m.Persons, m.Families, m.PersonsNotes, m.FamiliesNotes)
+	if err != nil {
+		return fmt.Errorf("could not build the merged tree: %w", err)
+	}
+
+	for _, candidate := range duplicate.Find(t, *opts.Duplicates) {
+		if base(m.personOffsets, candidate.A) != base(m.personOffsets, candidate.B) {
+			union(m.person, candidate.A, candidate.B)
+		}
+	}
+
+	return nil
+}
+
+func appendMissing(indexes []int32, others ...int32) []int32 {
+	for _, other := range others {
+		found := false
+
+		for _, i := range indexes {
+			if i == other {
+				found = true
+
+				break
+			}
+		}
+
+		if !found {
+			indexes = append(indexes, other)
+		}
+	}
+
+	return indexes
+}
+
+func joinNotes(a, b string) string {
+	switch {
+	case a == "":
+		return b
+	case b == "" || a == b:
+		return a
+	default:
+		return a + "\n\n" + b
+	}
+}
+
+// fillPerson completes the person p with what the person q merged into it
+// knows and p does not.
+func fillPerson(p, q *api.Person) {
+	if p.BirthDate == nil && p.BirthPlace == nil {
+		p.BirthDate, p.BirthPlace = q.BirthDate, q.BirthPlace
+	}
+
+	if p.BaptismDate == nil && p.BaptismPlace == nil {
+		p.BaptismDate, p.BaptismPlace = q.BaptismDate, q.BaptismPlace
+	}
+
+	if p.DeathDate == nil && p.DeathPlace == nil {
+		p.DeathDate, p.DeathPlace = q.DeathDate, q.DeathPlace
+	}
+
+	if p.BurialDate == nil && p.BurialPlace == nil {
+		p.BurialDate, p.BurialPlace = q.BurialDate, q.BurialPlace
+	}
+
+	if p.Occupation == nil {
+		p.Occupation = q.Occupation
+	}
+
+	if p.GetDeathType() == api.DeathType_DONT_KNOW_IF_DEAD {
+		p.DeathType = q.DeathType
+	}
+
+	if p.Parents == nil {
+		p.Parents = q.Parents
+	}
+
+	type eventKey struct {
+		name   api.EventName
+		spouse int32
+	}
+
+	events := map[eventKey]bool{}
+	for _, event := range p.Events {
+		events[eventKey{event.GetName(), event.GetIndexSpouse()}] = true
+	}
+
+	for _, event := range q.Events {
+		if !events[eventKey{event.GetName(), event.GetIndexSpouse()}] {
+			p.Events = append(p.Events, event)
+		}
+	}
+
+	p.Families = appendMissing(p.Families, q.Families...)
+}
+
+// mergePersons merges every person into the one representing its set, then
+// merges the families of the same couple coming from different bases.
+func (m *merger) mergePersons() {
+	for i := range m.person {
+		find(m.person, int32(i))
+	}
+
+	shift(m.Persons, m.Families, func(i int32) int32 { return m.person[i] }, func(i int32) int32 { return i })
+
+	for i, rep := range m.person {
+		if rep != int32(i) {
+			fillPerson(m.Persons[rep], m.Persons[i])
+			m.PersonsNotes[rep] = joinNotes(m.PersonsNotes[rep], m.PersonsNotes[i])
+			m.Linked++
+		}
+	}
+
+	couples := map[[2]int32]int32{}
+
+	for i, f := range m.Families {
+		couple := [2]int32{f.GetFather(), f.GetMother()}
+
+		first, ok := couples[couple]
+		if !ok {
+			couples[couple] = int32(i)
+
+			continue
+		}
+
+		// The families of the same couple in a single base are kept apart.
+		if base(m.familyOffsets, first) == base(m.familyOffsets, int32(i)) {
+			continue
+		}
+
+		m.family[i] = first
+		g := m.Families[first]
+		g.Children = appendMissing(g.Children, f.Children...)
+		g.Witnesses = appendMissing(g.Witnesses, f.Witnesses...)
+
+		if g.MarriageDate == nil && g.MarriagePlace == nil {
+			g.MarriageDate, g.MarriagePlace = f.MarriageDate, f.MarriagePlace
+		}
+
+		m.FamiliesNotes[first] = joinNotes(m.FamiliesNotes[first], m.FamiliesNotes[i])
+	}
+
+	shift(m.Persons, m.Families, func(i int32) int32 { return i }, func(i int32) int32 { return m.family[i] })
+
+	for _, p := range m.Persons {
+		p.Families = appendMissing(nil, p.Families...)
+	}
+
+	for _, f := range m.Families {
+		f.Children = appendMissing(nil, f.Children...)
+	}
+}
+
+// compact drops the records merged into another one, and renumbers the others
+// by their position.
+func (m *merger) compact() {
+	personIndexes := make([]int32, len(m.Persons))
+	familyIndexes := make([]int32, len(m.Families))
+
+	var (
+		persons                []*api.Person
+		families               []*api.Family
+		personsNotes, famNotes []string
+	)
+
+	for i, p := range m.Persons {
+		if m.person[i] == int32(i) {
+			personIndexes[i] = int32(len(persons))
+			persons = append(persons, p)
+			personsNotes = append(personsNotes, m.PersonsNotes[i])
+		}
+	}
+
+	for i, f := range m.Families {
+		if m.family[i] == int32(i) {
+			familyIndexes[i] = int32(len(families))
+			families = append(families, f)
+			famNotes = append(famNotes, m.FamiliesNotes[i])
+		}
+	}
+
+	shift(persons, families, func(i int32) int32 { return personIndexes[i] },
+		func(i int32) int32 { return familyIndexes[i] })
+
+	m.Persons, m.Families = persons, families
+	m.PersonsNotes, m.FamiliesNotes = personsNotes, famNotes
+}
+
+// Merge combines the bases found in paths into a single one, the persons and
+// the families of each base following the ones of the previous bases. The
+// persons which are the same are merged into the one of the first base, which
+// is completed with the events, dates and places only the others know, and
+// the families of the same couple are merged together.
+func Merge(paths []string, opts Options) (*Base, error) {
+	m := &merger{}
+
+	if err := m.load(paths); err != nil {
+		return nil, err
+	}
+
+	if err := m.link(opts); err != nil {
+		return nil, err
+	}
+
+	m.mergePersons()
+	m.compact()
+
+	return &m.Base, nil
+}
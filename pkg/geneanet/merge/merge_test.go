@@ -0,0 +1,145 @@
+package merge
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/trois-six/geneparse/pkg/geneanet/api"
+	"github.com/trois-six/geneparse/pkg/geneanet/duplicate"
+	"github.com/trois-six/geneparse/pkg/geneanet/gengedcom"
+	"github.com/trois-six/geneparse/pkg/geneanet/tree/treetest"
+	"github.com/trois-six/geneparse/pkg/geneanet/utils"
+	"google.golang.org/protobuf/proto"
+)
+
+// writeBases writes two bases sharing a couple and their son Jean: the first
+// base knows the birth of Jean, the second one his death, his brother Paul,
+// and Anne, who is not related.
+func writeBases(t *testing.T) []string {
+	t.Helper()
+
+	first, second := &treetest.Builder{}, &treetest.Builder{}
+
+	father := first.Person(api.Sex_MALE, "Pierre", "Martin")
+	mother := first.Person(api.Sex_FEMALE, "Marie", "Durand")
+	jean := first.Person(api.Sex_MALE, "Jean", "Martin")
+	jean.BirthDate = treetest.Date(1880, 1, 1)
+	first.Family(father, mother, jean)
+
+	father = second.Person(api.Sex_MALE, "Pierre", "Martin")
+	mother = second.Person(api.Sex_FEMALE, "Marie", "Durand")
+	jean = second.Person(api.Sex_MALE, "Jean", "Martin")
+	jean.BirthPlace = proto.String("Lyon")
+	treetest.Died(jean, treetest.Date(1950, 1, 1))
+	paul := second.Person(api.Sex_MALE, "Paul", "Martin")
+	second.Person(api.Sex_FEMALE, "Anne", "Leroy")
+	second.Family(father, mother, jean, paul).MarriagePlace = proto.String("Paris")
+
+	paths := []string{t.TempDir(), t.TempDir()}
+	first.Write(t, paths[0])
+	second.Write(t, paths[1])
+
+	return paths
+}
+
+func TestMerge(t *testing.T) {
+	links, err := ReadLinks(strings.NewReader("# the couple\n1:0 2:0\n1:1 2:1\n\n1:2 2:2\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := duplicate.DefaultOptions()
+
+	for _, tc := range []struct {
+		name string
+		opts Options
+	}{
+		{"links", Options{Links: links}},
+		{"duplicates", Options{Duplicates: &opts}},
+	} {
+		b, err := Merge(writeBases(t), tc.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if b.Linked != 3 {
+			t.Errorf("%s: %d persons linked, want 3", tc.name, b.Linked)
+		}
+
+		var names []string
+		for k, p := range b.Persons {
+			if p.GetIndex() != int32(k) {
+				t.Errorf("%s: person %d has index %d", tc.name, k, p.GetIndex())
+			}
+
+			names = append(names, p.GetFirstname())
+		}
+
+		if want := []string{"Pierre", "Marie", "Jean", "Paul", "Anne"}; !reflect.DeepEqual(names, want) {
+			t.Errorf("%s: persons = %v, want %v", tc.name, names, want)
+		}
+
+		if len(b.Families) != 1 {
+			t.Fatalf("%s: %d families, want 1", tc.name, len(b.Families))
+		}
+
+		f := b.Families[0]
+		if f.GetFather() != 0 || f.GetMother() != 1 || !reflect.DeepEqual(f.GetChildren(), []int32{2, 3}) ||
+			f.GetMarriagePlace() != "Paris" {
+			t.Errorf("%s: family = %v", tc.name, f)
+		}
+
+		jean, paul := b.Persons[2], b.Persons[3]
+		if gengedcom.DateString(jean.GetBirthDate()) != "1 JAN 1880" || jean.GetBirthPlace() != "" ||
+			gengedcom.DateString(jean.GetDeathDate()) != "1 JAN 1950" {
+			t.Errorf("%s: Jean was not completed: %v", tc.name, jean)
+		}
+
+		if paul.GetParents() != 0 || !reflect.DeepEqual(b.Persons[0].GetFamilies(), []int32{0}) ||
+			len(b.Persons[4].GetFamilies()) != 0 {
+			t.Errorf("%s: links between persons and families were not remapped", tc.name)
+		}
+	}
+}
+
+func TestMergeErrors(t *testing.T) {
+	for _, link := range []Link{
+		{A: Ref{Base: 1, Index: 0}, B: Ref{Base: 3, Index: 0}},
+		{A: Ref{Base: 1, Index: 3}, B: Ref{Base: 2, Index: 0}},
+		{A: Ref{Base: 1, Index: 0}, B: Ref{Base: 2, Index: -1}},
+	} {
+		if _, err := Merge(writeBases(t), Options{Links: []Link{link}}); !errors.Is(err, utils.ErrIndexOutOfRange) {
+			t.Errorf("Merge() with link %s %s: error = %v, want %v", link.A, link.B, err, utils.ErrIndexOutOfRange)
+		}
+	}
+}
+
+func TestReadLinks(t *testing.T) {
+	for _, tc := range []struct {
+		input string
+		want  []Link
+		err   bool
+	}{
+		{input: "", want: nil},
+		{input: "1:12 2:40", want: []Link{{A: Ref{1, 12}, B: Ref{2, 40}}}},
+		{input: "  # comment\n\n1:0\t3:7  \n", want: []Link{{A: Ref{1, 0}, B: Ref{3, 7}}}},
+		{input: "1:12", err: true},
+		{input: "1:12 2:40 3:1", err: true},
+		{input: "1-12 2:40", err: true},
+		{input: "a:12 2:40", err: true},
+		{input: "1:12 2:x", err: true},
+	} {
+		links, err := ReadLinks(strings.NewReader(tc.input))
+
+		switch {
+		case tc.err && !errors.Is(err, utils.ErrFileMalFormatted):
+			t.Errorf("ReadLinks(%q) error = %v, want %v", tc.input, err, utils.ErrFileMalFormatted)
+		case !tc.err && err != nil:
+			t.Errorf("ReadLinks(%q) error = %v", tc.input, err)
+		case !reflect.DeepEqual(links, tc.want):
+			t.Errorf("ReadLinks(%q) = %v, want %v", tc.input, links, tc.want)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package relationship
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/trois-six/geneparse/pkg/geneanet/api"
+)
+
+// gendered picks the masculine, feminine or neutral form of a term after the
+// sex of p.
+func gendered(sex api.Sex, male, female, neutral string) string {
+	switch sex {
+	case api.Sex_MALE:
+		return male
+	case api.Sex_FEMALE:
+		return female
+	case api.Sex_UNKNOWN:
+		return neutral
+	default:
+		return neutral
+	}
+}
+
+func ordinal(n int) string {
+	suffix := "th"
+
+	switch {
+	case n%100 >= 11 && n%100 <= 13:
+	case n%10 == 1:
+		suffix = "st"
+	case n%10 == 2:
+		suffix = "nd"
+	case n%10 == 3:
+		suffix = "rd"
+	}
+
+	return fmt.Sprintf("%d%s", n, suffix)
+}
+
+// greatEnglish prefixes term with the "great-" marks of generation n, term
+// standing for generation 2.
+func greatEnglish(n int, term string) string {
+	switch {
+	case n <= 2:
+		return term
+	case n == 3:
+		return "great-" + term
+	default:
+		return ordinal(n-2) + " great-" + term
+	}
+}
+
+func removedEnglish(n int) string {
+	switch n {
+	case 0:
+		return ""
+	case 1:
+		return " once removed"
+	case 2:
+		return " twice removed"
+	default:
+		return fmt.Sprintf(" %d times removed", n)
+	}
+}
+
+func bloodEnglish(r *Relationship) string { //nolint:cyclop
+	sex := r.A.GetSex()
+	half := ""
+
+	if r.Half {
+		half = "half-"
+	}
+
+	switch {
+	case r.Up == 0 && r.Down == 1:
+		return gendered(sex, "father", "mother", "parent")
+	case r.Up == 0:
+		return greatEnglish(r.Down, "grand"+gendered(sex, "father", "mother", "parent"))
+	case r.Down == 0 && r.Up == 1:
+		return gendered(sex, "son", "daughter", "child")
+	case r.Down == 0:
+		return greatEnglish(r.Up, "grand"+gendered(sex, "son", "daughter", "child"))
+	case r.Up == 1 && r.Down == 1:
+		return half + gendered(sex, "brother", "sister", "sibling")
+	case r.Up == 1:
+		return half + greatEnglish(r.Down, gendered(sex, "uncle", "aunt", "uncle or aunt"))
+	case r.Down == 1:
+		return half + greatEnglish(r.Up, gendered(sex, "nephew", "niece", "nephew or niece"))
+	default:
+		degree, removed := r.Up, r.Down-r.Up
+		if r.Down < r.Up {
+			degree, removed = r.Down, r.Up-r.Down
+		}
+
+		return half + ordinal(degree-1) + " cousin" + removedEnglish(removed)
+	}
+}
+
+// English names what A is to B, like "2nd cousin once removed".
+func (r *Relationship) English() string {
+	sex := r.A.GetSex()
+
+	switch r.Kind {
+	case Same:
+		return "same person"
+	case Blood:
+		return bloodEnglish(r)
+	case Spouse:
+		return gendered(sex, "husband", "wife", "spouse")
+	case StepParent:
+		return gendered(sex, "stepfather", "stepmother", "step-parent")
+	case StepChild:
+		return gendered(sex, "stepson", "stepdaughter", "stepchild")
+	case StepSibling:
+		return gendered(sex, "stepbrother", "stepsister", "step-sibling")
+	case None:
+		return "not related"
+	default:
+		return "not related"
+	}
+}
+
+// arriereFrench prefixes term with the "arrière-" marks of generation n, term
+// standing for generation 2, and falls back to beyond after the 5th generation.
+func arriereFrench(n int, term, beyond string) string {
+	switch {
+	case n <= 2:
+		return term
+	case n <= 5:
+		return strings.Repeat("arrière-", n-2) + term
+	default:
+		return fmt.Sprintf("%s à la %de génération", beyond, n)
+	}
+}
+
+func bloodFrench(r *Relationship) string { //nolint:cyclop
+	sex := r.A.GetSex()
+	half := ""
+
+	if r.Half {
+		half = "demi-"
+	}
+
+	switch {
+	case r.Up == 0 && r.Down == 1:
+		return gendered(sex, "père", "mère", "parent")
+	case r.Up == 0:
+		return arriereFrench(r.Down, gendered(sex, "grand-père", "grand-mère", "grand-parent"),
+			"ancêtre")
+	case r.Down == 0 && r.Up == 1:
+		return gendered(sex, "fils", "fille", "enfant")
+	case r.Down == 0:
+		return arriereFrench(r.Up, gendered(sex, "petit-fils", "petite-fille", "petit-enfant"),
+			gendered(sex, "descendant", "descendante", "descendant"))
+	case r.Up == 1 && r.Down == 1:
+		return half + gendered(sex, "frère", "sœur", "frère ou sœur")
+	case r.Up == 1 && r.Down == 2:
+		return half + gendered(sex, "oncle", "tante", "oncle ou tante")
+	case r.Up == 1:
+		return half + arriereFrench(r.Down-1, gendered(sex, "grand-oncle", "grand-tante", "grand-oncle ou grand-tante"),
+			gendered(sex, "oncle", "tante", "oncle ou tante"))
+	case r.Down == 1 && r.Up == 2:
+		return half + gendered(sex, "neveu", "nièce", "neveu ou nièce")
+	case r.Down == 1:
+		return half + arriereFrench(r.Up-1, gendered(sex, "petit-neveu", "petite-nièce", "petit-neveu ou petite-nièce"),
+			gendered(sex, "neveu", "nièce", "neveu ou nièce"))
+	default:
+		return half + cousinFrench(r)
+	}
+}
+
+func cousinFrench(r *Relationship) string {
+	sex := r.A.GetSex()
+
+	degree, removed := r.Up, r.Down-r.Up
+	if r.Down < r.Up {
+		degree, removed = r.Down, r.Up-r.Down
+	}
+
+	var name string
+
+	switch degree - 1 {
+	case 1:
+		name = gendered(sex, "cousin germain", "cousine germaine", "cousin germain")
+	case 2:
+		name = gendered(sex, "cousin issu de germain", "cousine issue de germain", "cousin issu de germain")
+	default:
+		name = fmt.Sprintf("%s au %de degré", gendered(sex, "cousin", "cousine", "cousin"), degree-1)
+	}
+
+	switch removed {
+	case 0:
+		return name
+	case 1:
+		return name + ", décalé d'une génération"
+	default:
+		return fmt.Sprintf("%s, décalé de %d générations", name, removed)
+	}
+}
+
+// French names what A is to B, like "cousin au 3e degré".
+func (r *Relationship) French() string {
+	sex := r.A.GetSex()
+
+	switch r.Kind {
+	case Same:
+		return "même personne"
+	case Blood:
+		return bloodFrench(r)
+	case Spouse:
+		return gendered(sex, "époux", "épouse", "conjoint")
+	case StepParent:
+		return gendered(sex, "beau-père", "belle-mère", "beau-parent")
+	case StepChild:
+		return gendered(sex, "beau-fils", "belle-fille", "bel-enfant")
+	case StepSibling:
+		return gendered(sex, "quasi-frère", "quasi-sœur", "quasi-frère ou quasi-sœur")
+	case None:
+		return "sans lien de parenté"
+	default:
+		return "sans lien de parenté"
+	}
+}
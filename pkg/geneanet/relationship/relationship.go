@@ -0,0 +1,191 @@
+package relationship
+
+import (
+	"fmt"
+
+	"github.com/trois-six/geneparse/pkg/geneanet/tree"
+	"github.com/trois-six/geneparse/pkg/geneanet/utils"
+)
+
+type Kind int
+
+const (
+	None Kind = iota
+	Same
+	Blood
+	Spouse
+	StepParent
+	StepChild
+	StepSibling
+)
+
+// Relationship describes what A is to B. For blood relationships, Up is the
+// number of generations between A and the closest common ancestors, and Down
+// the number of generations between them and B.
+type Relationship struct {
+	A, B      *tree.Person
+	Kind      Kind
+	Up, Down  int
+	Half      bool
+	Ancestors []*tree.Person
+}
+
+// ancestors returns the distance in generations from p to each of its
+// ancestors, p included, keeping the shortest one.
+func ancestors(p *tree.Person) map[*tree.Person]int {
+	distances := map[*tree.Person]int{p: 0}
+	generation := []*tree.Person{p}
+
+	for d := 1; len(generation) > 0; d++ {
+		var next []*tree.Person
+
+		for _, person := range generation {
+			for _, parent := range []*tree.Person{person.Father(), person.Mother()} {
+				if parent == nil {
+					continue
+				}
+
+				if _, ok := distances[parent]; !ok {
+					distances[parent] = d
+					next = append(next, parent)
+				}
+			}
+		}
+
+		generation = next
+	}
+
+	return distances
+}
+
+// isCouple tells whether persons contains both spouses of one family.
+func isCouple(persons []*tree.Person) bool {
+	set := make(map[*tree.Person]bool, len(persons))
+	for _, p := range persons {
+		set[p] = true
+	}
+
+	for _, p := range persons {
+		for _, family := range p.Families() {
+			if family.Father() == p && set[family.Mother()] {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func findBlood(r *Relationship) bool {
+	ancestorsA, ancestorsB := ancestors(r.A), ancestors(r.B)
+	best := -1
+
+	for person, upA := range ancestorsA {
+		downB, ok := ancestorsB[person]
+		if !ok {
+			continue
+		}
+
+		switch sum := upA + downB; {
+		case best < 0 || sum < best || (sum == best && upA < r.Up):
+			best = sum
+			r.Up, r.Down = upA, downB
+			r.Ancestors = []*tree.Person{person}
+		case sum == best && upA == r.Up:
+			r.Ancestors = append(r.Ancestors, person)
+		}
+	}
+
+	if best < 0 {
+		return false
+	}
+
+	r.Kind = Blood
+	r.Half = r.Up > 0 && r.Down > 0 && !isCouple(r.Ancestors)
+
+	return true
+}
+
+func contains(persons []*tree.Person, p *tree.Person) bool {
+	for _, e := range persons {
+		if e == p {
+			return true
+		}
+	}
+
+	return false
+}
+
+func parents(p *tree.Person) []*tree.Person {
+	var persons []*tree.Person
+
+	for _, parent := range []*tree.Person{p.Father(), p.Mother()} {
+		if parent != nil {
+			persons = append(persons, parent)
+		}
+	}
+
+	return persons
+}
+
+func findStep(r *Relationship) bool {
+	parentsA, parentsB := parents(r.A), parents(r.B)
+
+	switch {
+	case contains(r.B.Spouses(), r.A):
+		r.Kind = Spouse
+	case !contains(parentsB, r.A) && containsAny(r.A.Spouses(), parentsB):
+		r.Kind = StepParent
+	case !contains(parentsA, r.B) && containsAny(r.B.Spouses(), parentsA):
+		r.Kind = StepChild
+	default:
+		for _, parentA := range parentsA {
+			if containsAny(parentA.Spouses(), parentsB) {
+				r.Kind = StepSibling
+
+				return true
+			}
+		}
+
+		return false
+	}
+
+	return true
+}
+
+func containsAny(persons, others []*tree.Person) bool {
+	for _, p := range others {
+		if contains(persons, p) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Find computes the relationship between the persons of indexes a and b: blood
+// relationships through their closest common ancestors first, then spouses
+// and step relationships.
+func Find(t *tree.Tree, a, b int32) (*Relationship, error) {
+	r := &Relationship{A: t.Person(a), B: t.Person(b)}
+
+	if r.A == nil {
+		return nil, fmt.Errorf("%w: person %d", utils.ErrIndexOutOfRange, a)
+	}
+
+	if r.B == nil {
+		return nil, fmt.Errorf("%w: person %d", utils.ErrIndexOutOfRange, b)
+	}
+
+	if r.A == r.B {
+		r.Kind = Same
+
+		return r, nil
+	}
+
+	if !findBlood(r) {
+		findStep(r)
+	}
+
+	return r, nil
+}
@@ -0,0 +1,111 @@
+package relationship
+
+import (
+	"testing"
+
+	"github.com/trois-six/geneparse/pkg/geneanet/api"
+	"github.com/trois-six/geneparse/pkg/geneanet/tree/treetest"
+)
+
+func TestFind(t *testing.T) {
+	b := &treetest.Builder{}
+	grandfather := b.Person(api.Sex_MALE, "Louis", "Martin")
+	grandmother := b.Person(api.Sex_FEMALE, "Rose", "Petit")
+	father := b.Person(api.Sex_MALE, "Pierre", "Martin")
+	uncle := b.Person(api.Sex_MALE, "Paul", "Martin")
+	mother := b.Person(api.Sex_FEMALE, "Marie", "Durand")
+	jean := b.Person(api.Sex_MALE, "Jean", "Martin")
+	jeanne := b.Person(api.Sex_FEMALE, "Jeanne", "Martin")
+	stepmother := b.Person(api.Sex_FEMALE, "Louise", "Bernard")
+	halfBrother := b.Person(api.Sex_MALE, "Luc", "Martin")
+	aunt := b.Person(api.Sex_FEMALE, "Anne", "Leroy")
+	cousin := b.Person(api.Sex_MALE, "Marc", "Martin")
+	wife := b.Person(api.Sex_FEMALE, "Claire", "Moreau")
+	daughter := b.Person(api.Sex_FEMALE, "Alice", "Martin")
+	stepmotherHusband := b.Person(api.Sex_MALE, "Henri", "Roux")
+	stepsister := b.Person(api.Sex_FEMALE, "Eve", "Roux")
+
+	b.Family(grandfather, grandmother, father, uncle)
+	b.Family(father, mother, jean, jeanne)
+	b.Family(father, stepmother, halfBrother)
+	b.Family(uncle, aunt, cousin)
+	b.Family(jean, wife, daughter)
+	b.Family(stepmotherHusband, stepmother, stepsister)
+
+	tr := b.Tree(t)
+
+	for _, tc := range []struct {
+		a, b            *api.Person
+		english, french string
+	}{
+		{jean, jean, "same person", "même personne"},
+		{father, jean, "father", "père"},
+		{jean, father, "son", "fils"},
+		{grandfather, jean, "grandfather", "grand-père"},
+		{grandmother, daughter, "great-grandmother", "arrière-grand-mère"},
+		{daughter, father, "granddaughter", "petite-fille"},
+		{jeanne, jean, "sister", "sœur"},
+		{halfBrother, jean, "half-brother", "demi-frère"},
+		{uncle, jean, "uncle", "oncle"},
+		{uncle, daughter, "great-uncle", "grand-oncle"},
+		{jeanne, uncle, "niece", "nièce"},
+		{daughter, uncle, "great-niece", "petite-nièce"},
+		{cousin, jean, "1st cousin", "cousin germain"},
+		{cousin, daughter, "1st cousin once removed", "cousin germain, décalé d'une génération"},
+		{daughter, cousin, "1st cousin once removed", "cousine germaine, décalé d'une génération"},
+		{mother, father, "wife", "épouse"},
+		{stepmother, jean, "stepmother", "belle-mère"},
+		{jean, stepmother, "stepson", "beau-fils"},
+		{stepsister, jean, "stepsister", "quasi-sœur"},
+		{stepsister, halfBrother, "half-sister", "demi-sœur"},
+		{stepmotherHusband, aunt, "not related", "sans lien de parenté"},
+	} {
+		r, err := Find(tr, tc.a.GetIndex(), tc.b.GetIndex())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := r.English(); got != tc.english {
+			t.Errorf("%s to %s: English() = %q, want %q", tc.a.GetFirstname(), tc.b.GetFirstname(), got, tc.english)
+		}
+
+		if got := r.French(); got != tc.french {
+			t.Errorf("%s to %s: French() = %q, want %q", tc.a.GetFirstname(), tc.b.GetFirstname(), got, tc.french)
+		}
+	}
+}
+
+func TestNames(t *testing.T) {
+	b := &treetest.Builder{}
+	man := b.Person(api.Sex_MALE, "Jean", "Martin")
+	woman := b.Person(api.Sex_FEMALE, "Jeanne", "Martin")
+	b.Family(man, woman)
+
+	tr := b.Tree(t)
+
+	for _, tc := range []struct {
+		a               *api.Person
+		up, down        int
+		half            bool
+		english, french string
+	}{
+		{man, 0, 5, false, "3rd great-grandfather", "arrière-arrière-arrière-grand-père"},
+		{man, 0, 6, false, "4th great-grandfather", "ancêtre à la 6e génération"},
+		{woman, 7, 0, false, "5th great-granddaughter", "descendante à la 7e génération"},
+		{man, 1, 4, false, "2nd great-uncle", "arrière-grand-oncle"},
+		{man, 3, 3, false, "2nd cousin", "cousin issu de germain"},
+		{woman, 4, 4, true, "half-3rd cousin", "demi-cousine au 3e degré"},
+		{man, 2, 5, false, "1st cousin 3 times removed", "cousin germain, décalé de 3 générations"},
+		{man, 13, 12, false, "11th cousin once removed", "cousin au 11e degré, décalé d'une génération"},
+	} {
+		r := &Relationship{A: tr.Person(tc.a.GetIndex()), Kind: Blood, Up: tc.up, Down: tc.down, Half: tc.half}
+
+		if got := r.English(); got != tc.english {
+			t.Errorf("up %d, down %d: English() = %q, want %q", tc.up, tc.down, got, tc.english)
+		}
+
+		if got := r.French(); got != tc.french {
+			t.Errorf("up %d, down %d: French() = %q, want %q", tc.up, tc.down, got, tc.french)
+		}
+	}
+}
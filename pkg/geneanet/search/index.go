@@ -0,0 +1,245 @@
+package search
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/trois-six/geneparse/pkg/geneanet/api"
+	"github.com/trois-six/geneparse/pkg/geneanet/gengedcom"
+	"github.com/trois-six/geneparse/pkg/geneanet/tree"
+	"github.com/trois-six/geneparse/pkg/geneanet/utils"
+)
+
+// Result is a person matching a query.
+type Result struct {
+	Index     int32  `json:"index"`
+	Firstname string `json:"firstname"`
+	Lastname  string `json:"lastname"`
+	Occ       int32  `json:"occ"`
+	Birth     string `json:"birth,omitempty"`
+	Death     string `json:"death,omitempty"`
+}
+
+// postings maps the normalised words of a field to the sorted indexes of the
+// persons having them.
+type postings map[string][]int32
+
+func (p postings) add(index int32, values ...string) {
+	for _, value := range values {
+		for _, word := range strings.Fields(utils.Normalize(value)) {
+			list := p[word]
+			if len(list) == 0 || list[len(list)-1] != index {
+				p[word] = append(list, index)
+			}
+		}
+	}
+}
+
+// Index is an inverted index of the names and places of the persons of a
+// tree, built once to run many queries.
+type Index struct {
+	tree   *tree.Tree
+	fields map[Field]postings
+	// vocabulary holds the sorted words of each field, to look prefixes up
+	vocabulary map[Field][]string
+}
+
+func personPlaces(p *tree.Person) []string {
+	places := []string{p.GetBirthPlace(), p.GetBaptismPlace(), p.GetDeathPlace(), p.GetBurialPlace()}
+
+	for _, event := range p.GetEvents() {
+		places = append(places, event.GetPlace())
+	}
+
+	for _, family := range p.Families() {
+		places = append(places, family.GetMarriagePlace())
+	}
+
+	return places
+}
+
+// NewIndex indexes the persons of the tree.
+func NewIndex(t *tree.Tree) *Index {
+	idx := &Index{
+		tree: t,
+		fields: map[Field]postings{
+			FieldName:      {},
+			FieldSurname:   {},
+			FieldFirstname: {},
+			FieldPlace:     {},
+		},
+		vocabulary: map[Field][]string{},
+	}
+
+	for _, p := range t.Persons() {
+		i := p.GetIndex()
+
+		surnames := append([]string{p.GetLastname()}, p.GetSurnameAliases()...)
+		firstnames := append([]string{p.GetFirstname(), p.GetPublicName()}, p.GetFirstnameAliases()...)
+
+		idx.fields[FieldSurname].add(i, surnames...)
+		idx.fields[FieldFirstname].add(i, firstnames...)
+		idx.fields[FieldName].add(i, surnames...)
+		idx.fields[FieldName].add(i, firstnames...)
+		idx.fields[FieldName].add(i, p.GetAliases()...)
+		idx.fields[FieldPlace].add(i, personPlaces(p)...)
+	}
+
+	for field, words := range idx.fields {
+		vocabulary := make([]string, 0, len(words))
+
+		for word := range words {
+			vocabulary = append(vocabulary, word)
+		}
+
+		sort.Strings(vocabulary)
+		idx.vocabulary[field] = vocabulary
+	}
+
+	return idx
+}
+
+// union merges sorted lists of indexes.
+func union(lists [][]int32) []int32 {
+	if len(lists) == 1 {
+		return lists[0]
+	}
+
+	seen := map[int32]bool{}
+
+	var merged []int32
+
+	for _, list := range lists {
+		for _, i := range list {
+			if !seen[i] {
+				seen[i] = true
+				merged = append(merged, i)
+			}
+		}
+	}
+
+	sort.Slice(merged, func(i, j int) bool { return merged[i] < merged[j] })
+
+	return merged
+}
+
+// intersect returns the indexes of a which are also in b, both being sorted.
+func intersect(a, b []int32) []int32 {
+	var common []int32
+
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] < b[j]:
+			i++
+		case a[i] > b[j]:
+			j++
+		default:
+			common = append(common, a[i])
+			i++
+			j++
+		}
+	}
+
+	return common
+}
+
+// lookup returns the persons having word in field, or a word starting with it
+// when prefix is set.
+func (idx *Index) lookup(field Field, word string, prefix bool) []int32 {
+	if !prefix {
+		return idx.fields[field][word]
+	}
+
+	vocabulary := idx.vocabulary[field]
+
+	var lists [][]int32
+
+	for i := sort.SearchStrings(vocabulary, word); i < len(vocabulary) && strings.HasPrefix(vocabulary[i], word); i++ {
+		lists = append(lists, idx.fields[field][vocabulary[i]])
+	}
+
+	if len(lists) == 0 {
+		return nil
+	}
+
+	return union(lists)
+}
+
+// match returns the persons matching a term.
+func (idx *Index) match(term Term) []int32 {
+	var matches []int32
+
+	for i, word := range term.Words {
+		prefix := term.Prefix && i == len(term.Words)-1
+
+		var found []int32
+
+		if term.Field == FieldAny {
+			found = union([][]int32{idx.lookup(FieldName, word, prefix), idx.lookup(FieldPlace, word, prefix)})
+		} else {
+			found = idx.lookup(term.Field, word, prefix)
+		}
+
+		if i == 0 {
+			matches = found
+		} else {
+			matches = intersect(matches, found)
+		}
+	}
+
+	return matches
+}
+
+func inRange(date *api.Date, r *YearRange) bool {
+	if r == nil {
+		return true
+	}
+
+	year, ok := utils.DateYear(date)
+
+	return ok && year >= r.From && year <= r.To
+}
+
+func newResult(p *tree.Person) Result {
+	return Result{
+		Index:     p.GetIndex(),
+		Firstname: p.GetFirstname(),
+		Lastname:  p.GetLastname(),
+		Occ:       p.GetOcc(),
+		Birth:     gengedcom.DateString(p.GetBirthDate()),
+		Death:     gengedcom.DateString(p.GetDeathDate()),
+	}
+}
+
+// Search returns the persons matching all the terms and ranges of the query,
+// by increasing index.
+func (idx *Index) Search(q *Query) []Result {
+	var candidates []int32
+
+	for i, term := range q.Terms {
+		if i == 0 {
+			candidates = idx.match(term)
+		} else {
+			candidates = intersect(candidates, idx.match(term))
+		}
+	}
+
+	if len(q.Terms) == 0 {
+		candidates = make([]int32, 0, len(idx.tree.Persons()))
+
+		for _, p := range idx.tree.Persons() {
+			candidates = append(candidates, p.GetIndex())
+		}
+	}
+
+	results := []Result{}
+
+	for _, i := range candidates {
+		p := idx.tree.Person(i)
+		if inRange(p.GetBirthDate(), q.Born) && inRange(p.GetDeathDate(), q.Died) {
+			results = append(results, newResult(p))
+		}
+	}
+
+	return results
+}
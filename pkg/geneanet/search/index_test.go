@@ -0,0 +1,52 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/trois-six/geneparse/pkg/geneanet/api"
+	"github.com/trois-six/geneparse/pkg/geneanet/tree/treetest"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestSearch(t *testing.T) {
+	b := &treetest.Builder{}
+	jean := b.Person(api.Sex_MALE, "Jean", "Martin")
+	jean.BirthDate, jean.BirthPlace = treetest.Date(1760, 1, 1), proto.String("Saint-Étienne")
+	marie := b.Person(api.Sex_FEMALE, "Marie", "Durand")
+	marie.BirthDate = treetest.Date(1765, 1, 1)
+	marie.SurnameAliases = []string{"Durant"}
+	jeanne := b.Person(api.Sex_FEMALE, "Jeanne", "Martin")
+	jeanne.BirthDate = treetest.Date(1790, 1, 1)
+	b.Family(jean, marie, jeanne).MarriagePlace = proto.String("Lyon")
+
+	idx := NewIndex(b.Tree(t))
+
+	for query, want := range map[string][]int32{
+		"martin":                   {0, 2},
+		"jean":                     {0},
+		"jean*":                    {0, 2},
+		"surname:durant":           {1},
+		"firstname:martin":         {},
+		"place:etienne":            {0},
+		"lyon":                     {0, 1},
+		"martin born:1750..1780":   {0},
+		"born:1780..":              {2},
+		"died:1700..":              {},
+		`place:"saint etienne" j*`: {0},
+	} {
+		q, err := ParseQuery(query)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got := []int32{}
+		for _, r := range idx.Search(q) {
+			got = append(got, r.Index)
+		}
+
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Search(%q) = %v, want %v", query, got, want)
+		}
+	}
+}
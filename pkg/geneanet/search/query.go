@@ -0,0 +1,191 @@
+package search
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/trois-six/geneparse/pkg/geneanet/utils"
+)
+
+// Field is the part of the persons a term is looked for in.
+type Field string
+
+const (
+	// FieldAny matches the names and the places.
+	FieldAny       Field = ""
+	FieldName      Field = "name"
+	FieldSurname   Field = "surname"
+	FieldFirstname Field = "firstname"
+	FieldPlace     Field = "place"
+
+	rangeSeparator = ".."
+	prefixWildcard = "*"
+)
+
+// mapFieldName maps the field names of the queries, and their synonyms, to the
+// fields. born and died are years, not fields.
+var mapFieldName = map[string]Field{ // nolint:gochecknoglobals
+	"name":      FieldName,
+	"surname":   FieldSurname,
+	"lastname":  FieldSurname,
+	"firstname": FieldFirstname,
+	"place":     FieldPlace,
+}
+
+// Term is a value looked for in a field: all its words must be found, the last
+// one being only a prefix when it ended with a "*".
+type Term struct {
+	Field  Field
+	Words  []string
+	Prefix bool
+}
+
+// YearRange bounds a year, both bounds included.
+type YearRange struct {
+	From int32
+	To   int32
+}
+
+// Query is a parsed search: the persons must match all of its terms and
+// ranges.
+type Query struct {
+	Terms []Term
+	Born  *YearRange
+	Died  *YearRange
+}
+
+// tokenize splits a query on spaces, except inside double quotes, which are
+// dropped.
+func tokenize(s string) ([]string, error) {
+	var (
+		tokens  []string
+		current strings.Builder
+		quoted  bool
+	)
+
+	for _, r := range s {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == ' ' && !quoted:
+			if current.Len() > 0 {
+				tokens = append(tokens, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(r)
+		}
+	}
+
+	if quoted {
+		return nil, fmt.Errorf("%w: unbalanced quotes", utils.ErrInvalidQuery)
+	}
+
+	if current.Len() > 0 {
+		tokens = append(tokens, current.String())
+	}
+
+	return tokens, nil
+}
+
+func parseYear(s string, open int32) (int32, error) {
+	if s == "" {
+		return open, nil
+	}
+
+	year, err := strconv.ParseInt(s, utils.ConstDecBase, 32)
+	if err != nil {
+		return 0, fmt.Errorf("%w: bad year %q", utils.ErrInvalidQuery, s)
+	}
+
+	return int32(year), nil
+}
+
+// parseRange parses "1750", "1750..1800", "1750.." or "..1800".
+func parseRange(s string) (*YearRange, error) {
+	from, to := s, s
+
+	if i := strings.Index(s, rangeSeparator); i >= 0 {
+		from, to = s[:i], s[i+len(rangeSeparator):]
+	}
+
+	if s == "" || s == rangeSeparator {
+		return nil, fmt.Errorf("%w: empty year range", utils.ErrInvalidQuery)
+	}
+
+	var (
+		r   YearRange
+		err error
+	)
+
+	if r.From, err = parseYear(from, math.MinInt32); err != nil {
+		return nil, err
+	}
+
+	if r.To, err = parseYear(to, math.MaxInt32); err != nil {
+		return nil, err
+	}
+
+	return &r, nil
+}
+
+func parseTerm(field Field, value string) (Term, bool) {
+	term := Term{Field: field}
+
+	if strings.HasSuffix(value, prefixWildcard) {
+		term.Prefix = true
+		value = strings.TrimSuffix(value, prefixWildcard)
+	}
+
+	term.Words = strings.Fields(utils.Normalize(value))
+
+	return term, len(term.Words) > 0
+}
+
+// ParseQuery parses queries such as `surname:Martin born:1750..1800
+// place:"Saint Étienne" jean`, where the words without a field are looked for
+// in the names and the places.
+func ParseQuery(s string) (*Query, error) {
+	tokens, err := tokenize(s)
+	if err != nil {
+		return nil, err
+	}
+
+	q := &Query{}
+
+	for _, token := range tokens {
+		name, value := "", token
+
+		if i := strings.Index(token, ":"); i >= 0 {
+			name, value = strings.ToLower(token[:i]), token[i+1:]
+		}
+
+		switch name {
+		case "born", "birth":
+			if q.Born, err = parseRange(value); err != nil {
+				return nil, err
+			}
+		case "died", "death":
+			if q.Died, err = parseRange(value); err != nil {
+				return nil, err
+			}
+		default:
+			field, ok := mapFieldName[name]
+			if !ok && name != "" {
+				return nil, fmt.Errorf("%w: unknown field %q", utils.ErrInvalidQuery, name)
+			}
+
+			if term, ok := parseTerm(field, value); ok {
+				q.Terms = append(q.Terms, term)
+			}
+		}
+	}
+
+	if len(q.Terms) == 0 && q.Born == nil && q.Died == nil {
+		return nil, fmt.Errorf("%w: nothing to search", utils.ErrInvalidQuery)
+	}
+
+	return q, nil
+}
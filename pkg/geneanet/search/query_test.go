@@ -0,0 +1,58 @@
+package search
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/trois-six/geneparse/pkg/geneanet/utils"
+)
+
+func TestParseQuery(t *testing.T) {
+	for _, tc := range []struct {
+		query string
+		want  *Query
+		err   bool
+	}{
+		{query: "jean", want: &Query{Terms: []Term{{Field: FieldAny, Words: []string{"jean"}}}}},
+		{
+			query: `Surname:Martin place:"Saint-Étienne" jea*`,
+			want: &Query{Terms: []Term{
+				{Field: FieldSurname, Words: []string{"martin"}},
+				{Field: FieldPlace, Words: []string{"saint", "etienne"}},
+				{Field: FieldAny, Words: []string{"jea"}, Prefix: true},
+			}},
+		},
+		{query: "lastname:Dupont", want: &Query{Terms: []Term{{Field: FieldSurname, Words: []string{"dupont"}}}}},
+		{query: "born:1750", want: &Query{Born: &YearRange{From: 1750, To: 1750}}},
+		{query: "birth:1750..1800", want: &Query{Born: &YearRange{From: 1750, To: 1800}}},
+		{query: "died:1800..", want: &Query{Died: &YearRange{From: 1800, To: math.MaxInt32}}},
+		{query: "death:..1800", want: &Query{Died: &YearRange{From: math.MinInt32, To: 1800}}},
+		{
+			query: "firstname:jean born:1750 ?",
+			want: &Query{
+				Terms: []Term{{Field: FieldFirstname, Words: []string{"jean"}}},
+				Born:  &YearRange{From: 1750, To: 1750},
+			},
+		},
+		{query: "", err: true},
+		{query: "?", err: true},
+		{query: `place:"Saint`, err: true},
+		{query: "job:baker", err: true},
+		{query: "born:..", err: true},
+		{query: "born:", err: true},
+		{query: "born:17x0", err: true},
+	} {
+		q, err := ParseQuery(tc.query)
+
+		switch {
+		case tc.err && !errors.Is(err, utils.ErrInvalidQuery):
+			t.Errorf("ParseQuery(%q) error = %v, want %v", tc.query, err, utils.ErrInvalidQuery)
+		case !tc.err && err != nil:
+			t.Errorf("ParseQuery(%q) error = %v", tc.query, err)
+		case !reflect.DeepEqual(q, tc.want):
+			t.Errorf("ParseQuery(%q) = %+v, want %+v", tc.query, q, tc.want)
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package snapshot
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/trois-six/geneparse/pkg/geneanet/database"
+	"github.com/trois-six/geneparse/pkg/geneanet/utils"
+)
+
+const (
+	// Latest is the name of the symbolic link pointing to the latest snapshot
+	// of a tree.
+	Latest = "latest"
+	// DefaultKeep is the default number of snapshots kept for each tree.
+	DefaultKeep = 10
+
+	tmpPrefix = ".tmp-"
+)
+
+// Snapshot is a version of a tree, stored in a directory named after the
+// timestamp of its base info.
+type Snapshot struct {
+	Tree      string    `json:"tree"`
+	Timestamp int64     `json:"timestamp"`
+	Date      time.Time `json:"date"`
+	Dir       string    `json:"dir"`
+	Persons   uint32    `json:"persons"`
+	Latest    bool      `json:"latest"`
+}
+
+// Store holds the snapshots of trees, in <dir>/<tree>/<timestamp>/, with a
+// Latest link in the directory of each tree.
+type Store struct {
+	dir string
+}
+
+// New returns the store of the snapshots kept in dir.
+func New(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// Dir returns the directory of the snapshot of the tree with the timestamp.
+func (s *Store) Dir(tree string, timestamp int64) string {
+	return filepath.Join(s.dir, tree, strconv.FormatInt(timestamp, utils.ConstDecBase))
+}
+
+// LatestDir returns the path of the Latest link of the tree.
+func (s *Store) LatestDir(tree string) string {
+	return filepath.Join(s.dir, tree, Latest)
+}
+
+// Trees returns the trees having snapshots, sorted by name.
+func (s *Store) Trees() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading snapshot store: %w", err)
+	}
+
+	var trees []string
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		if _, err := os.Lstat(s.LatestDir(entry.Name())); err == nil {
+			trees = append(trees, entry.Name())
+		}
+	}
+
+	return trees, nil
+}
+
+func (s *Store) latestTimestamp(tree string) (int64, error) {
+	target, err := os.Readlink(s.LatestDir(tree))
+	if os.IsNotExist(err) {
+		return 0, fmt.Errorf("%w: %s", utils.ErrNoSnapshot, tree)
+	} else if err != nil {
+		return 0, fmt.Errorf("reading latest snapshot: %w", err)
+	}
+
+	timestamp, err := strconv.ParseInt(filepath.Base(target), utils.ConstDecBase, 0)
+	if err != nil {
+		return 0, fmt.Errorf("%w: latest snapshot %s", utils.ErrFileMalFormatted, target)
+	}
+
+	return timestamp, nil
+}
+
+// List returns the snapshots of the tree, from the oldest to the newest.
+func (s *Store) List(tree string) ([]Snapshot, error) {
+	entries, err := os.ReadDir(filepath.Join(s.dir, tree))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("reading snapshots of %s: %w", tree, err)
+	}
+
+	latest, err := s.latestTimestamp(tree)
+	if err != nil && !errors.Is(err, utils.ErrNoSnapshot) {
+		return nil, err
+	}
+
+	var snapshots []Snapshot
+
+	for _, entry := range entries {
+		timestamp, err := strconv.ParseInt(entry.Name(), utils.ConstDecBase, 0)
+		if !entry.IsDir() || err != nil {
+			continue
+		}
+
+		snapshot := Snapshot{
+			Tree:      tree,
+			Timestamp: timestamp,
+			Date:      time.Unix(timestamp, 0),
+			Dir:       s.Dir(tree, timestamp),
+			Latest:    timestamp == latest,
+		}
+
+		if info, err := database.ReadInfoBase(snapshot.Dir); err == nil {
+			snapshot.Persons = info.NbPersons
+		}
+
+		snapshots = append(snapshots, snapshot)
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].Timestamp < snapshots[j].Timestamp })
+
+	return snapshots, nil
+}
+
+// Latest returns the snapshot of the tree the Latest link points to.
+func (s *Store) Latest(tree string) (*Snapshot, error) {
+	snapshots, err := s.List(tree)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range snapshots {
+		if snapshots[i].Latest {
+			return &snapshots[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", utils.ErrNoSnapshot, tree)
+}
+
+// Add stores a snapshot of the tree with the timestamp, whose files are written
+// by extract into the directory it is given, and makes it the latest one. The
+// snapshot replaces the one with the same timestamp only once extract succeeds.
+func (s *Store) Add(tree string, timestamp int64, extract func(dir string) error) (*Snapshot, error) {
+	dir := s.Dir(tree, timestamp)
+	tmpDir := filepath.Join(filepath.Dir(dir), tmpPrefix+filepath.Base(dir))
+
+	if err := os.RemoveAll(tmpDir); err != nil {
+		return nil, fmt.Errorf("removing temporary snapshot: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dir), os.ModePerm|os.ModeDir); err != nil {
+		return nil, fmt.Errorf("creating tree directory: %w", err)
+	}
+
+	if err := extract(tmpDir); err != nil {
+		os.RemoveAll(tmpDir) //nolint:errcheck
+
+		return nil, err
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, fmt.Errorf("replacing snapshot: %w", err)
+	}
+
+	if err := os.Rename(tmpDir, dir); err != nil {
+		return nil, fmt.Errorf("storing snapshot: %w", err)
+	}
+
+	if err := s.SetLatest(tree, timestamp); err != nil {
+		return nil, err
+	}
+
+	return s.Latest(tree)
+}
+
+// SetLatest points the Latest link of the tree to its snapshot with the
+// timestamp, for instance to roll back to a previous version.
+func (s *Store) SetLatest(tree string, timestamp int64) error {
+	dir := s.Dir(tree, timestamp)
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return fmt.Errorf("%w: %s", utils.ErrUnknownSnapshot, dir)
+	}
+
+	link := s.LatestDir(tree)
+	tmpLink := filepath.Join(filepath.Dir(link), tmpPrefix+Latest)
+
+	if err := os.RemoveAll(tmpLink); err != nil {
+		return fmt.Errorf("removing temporary link: %w", err)
+	}
+
+	// The link is relative so that the store can be moved.
+	if err := os.Symlink(filepath.Base(dir), tmpLink); err != nil {
+		return fmt.Errorf("creating latest link: %w", err)
+	}
+
+	if err := os.Rename(tmpLink, link); err != nil {
+		return fmt.Errorf("replacing latest link: %w", err)
+	}
+
+	return nil
+}
+
+// Prune removes the oldest snapshots of the tree so that at most keep of them
+// remain, never removing the latest one, and returns the removed snapshots.
+// A keep of 0 or less keeps all the snapshots.
+func (s *Store) Prune(tree string, keep int) ([]Snapshot, error) {
+	if keep <= 0 {
+		return nil, nil
+	}
+
+	snapshots, err := s.List(tree)
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []Snapshot
+
+	for i := 0; i < len(snapshots) && len(snapshots)-len(removed) > keep; i++ {
+		if snapshots[i].Latest {
+			continue
+		}
+
+		if err := os.RemoveAll(snapshots[i].Dir); err != nil {
+			return removed, fmt.Errorf("removing snapshot: %w", err)
+		}
+
+		removed = append(removed, snapshots[i])
+	}
+
+	return removed, nil
+}
@@ -0,0 +1,139 @@
+package snapshot
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/trois-six/geneparse/pkg/geneanet/utils"
+)
+
+var errExtract = errors.New("extract failed")
+
+// add stores a snapshot holding a single file.
+func add(t *testing.T, s *Store, timestamp int64) {
+	t.Helper()
+
+	_, err := s.Add("tree", timestamp, func(dir string) error {
+		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+			return err
+		}
+
+		return os.WriteFile(filepath.Join(dir, "file"), []byte("content"), 0o600)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// timestamps returns the timestamps of the snapshots, the latest one being
+// negated.
+func timestamps(snapshots []Snapshot) []int64 {
+	list := []int64{}
+
+	for _, snapshot := range snapshots {
+		if snapshot.Latest {
+			list = append(list, -snapshot.Timestamp)
+		} else {
+			list = append(list, snapshot.Timestamp)
+		}
+	}
+
+	return list
+}
+
+func list(t *testing.T, s *Store) []int64 {
+	t.Helper()
+
+	snapshots, err := s.List("tree")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return timestamps(snapshots)
+}
+
+func TestAdd(t *testing.T) {
+	s := New(t.TempDir())
+
+	if _, err := s.Latest("tree"); !errors.Is(err, utils.ErrNoSnapshot) {
+		t.Errorf("Latest() error = %v, want %v", err, utils.ErrNoSnapshot)
+	}
+
+	add(t, s, 3)
+	add(t, s, 1)
+
+	if got, want := list(t, s), []int64{-1, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("List() = %v, want %v", got, want)
+	}
+
+	if _, err := s.Add("tree", 2, func(string) error { return errExtract }); !errors.Is(err, errExtract) {
+		t.Errorf("Add() error = %v, want %v", err, errExtract)
+	}
+
+	if got, want := list(t, s), []int64{-1, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("List() after a failed Add() = %v, want %v", got, want)
+	}
+
+	if err := s.SetLatest("tree", 3); err != nil {
+		t.Fatal(err)
+	}
+
+	latest, err := s.Latest("tree")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if latest.Timestamp != 3 || latest.Dir != s.Dir("tree", 3) {
+		t.Errorf("Latest() = %+v, want the snapshot 3", latest)
+	}
+
+	if err := s.SetLatest("tree", 2); !errors.Is(err, utils.ErrUnknownSnapshot) {
+		t.Errorf("SetLatest() error = %v, want %v", err, utils.ErrUnknownSnapshot)
+	}
+
+	if trees, err := s.Trees(); err != nil || !reflect.DeepEqual(trees, []string{"tree"}) {
+		t.Errorf("Trees() = %v, %v, want [tree]", trees, err)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		latest  int64
+		keep    int
+		removed []int64
+		kept    []int64
+	}{
+		{"keep all", 4, 0, []int64{}, []int64{1, 2, 3, -4}},
+		{"keep more than stored", 4, 5, []int64{}, []int64{1, 2, 3, -4}},
+		{"keep the newest", 4, 2, []int64{1, 2}, []int64{3, -4}},
+		{"keep an old latest", 1, 2, []int64{2, 3}, []int64{-1, 4}},
+		{"keep only the latest", 2, 1, []int64{1, 3, 4}, []int64{-2}},
+	} {
+		s := New(t.TempDir())
+
+		for timestamp := int64(1); timestamp <= 4; timestamp++ {
+			add(t, s, timestamp)
+		}
+
+		if err := s.SetLatest("tree", tc.latest); err != nil {
+			t.Fatal(err)
+		}
+
+		removed, err := s.Prune("tree", tc.keep)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := timestamps(removed); !reflect.DeepEqual(got, tc.removed) {
+			t.Errorf("%s: Prune() = %v, want %v", tc.name, got, tc.removed)
+		}
+
+		if got := list(t, s); !reflect.DeepEqual(got, tc.kept) {
+			t.Errorf("%s: List() = %v, want %v", tc.name, got, tc.kept)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package sosa
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/trois-six/geneparse/pkg/geneanet/tree"
+	"github.com/trois-six/geneparse/pkg/geneanet/utils"
+)
+
+// Numbers maps the index of each ancestor to its Sosa numbers, in increasing
+// order. An ancestor has several numbers when it is reached through several
+// lines (implex).
+type Numbers map[int32][]*big.Int
+
+type ancestor struct {
+	person *tree.Person
+	number *big.Int
+}
+
+// Root returns the index of the root person of the base, from which Sosa
+// numbers are computed by default.
+func Root(t *tree.Tree) int32 {
+	if t.Info() == nil {
+		return 0
+	}
+
+	return int32(t.Info().RootSosa)
+}
+
+const (
+	// MaxGenerations is the number of generations numbered when Compute is
+	// not given a lower bound.
+	MaxGenerations = 1000
+	// MaxNumbers is the maximum number of Sosa numbers of a person, the
+	// smallest ones being kept. The ancestors of a person are only reached
+	// through its kept numbers, so that heavy implex does not multiply them.
+	MaxNumbers = 64
+)
+
+// parents returns the known parents of the person.
+func parents(person *tree.Person) []*tree.Person {
+	var persons []*tree.Person
+
+	for _, parent := range []*tree.Person{person.Father(), person.Mother()} {
+		if parent != nil {
+			persons = append(persons, parent)
+		}
+	}
+
+	return persons
+}
+
+// checkLoops returns utils.ErrAncestryLoop when an ancestor of root is its own
+// ancestor, going up the lines depth first while tracking the persons on the
+// current line.
+func checkLoops(root *tree.Person) error {
+	const (
+		onLine = iota + 1
+		done
+	)
+
+	type line struct {
+		person  *tree.Person
+		parents []*tree.Person
+	}
+
+	state := map[int32]int{root.GetIndex(): onLine}
+	stack := []line{{person: root, parents: parents(root)}}
+
+	for len(stack) > 0 {
+		top := &stack[len(stack)-1]
+		if len(top.parents) == 0 {
+			state[top.person.GetIndex()] = done
+			stack = stack[:len(stack)-1]
+
+			continue
+		}
+
+		parent := top.parents[0]
+		top.parents = top.parents[1:]
+
+		switch state[parent.GetIndex()] {
+		case onLine:
+			return fmt.Errorf("%w: person %d", utils.ErrAncestryLoop, parent.GetIndex())
+		case done:
+			continue
+		}
+
+		state[parent.GetIndex()] = onLine
+		stack = append(stack, line{person: parent, parents: parents(parent)})
+	}
+
+	return nil
+}
+
+// Compute numbers the ancestors of root, root being 1, the father of a person
+// numbered n being 2n and its mother 2n+1. Only maxGenerations generations
+// above root are numbered, MaxGenerations when maxGenerations is 0, and each
+// person gets at most MaxNumbers numbers. It fails with utils.ErrAncestryLoop
+// when an ancestor of root is its own ancestor.
+func Compute(t *tree.Tree, root int32, maxGenerations int) (Numbers, error) {
+	person := t.Person(root)
+	if person == nil {
+		return nil, fmt.Errorf("%w: root person %d", utils.ErrIndexOutOfRange, root)
+	}
+
+	if err := checkLoops(person); err != nil {
+		return nil, err
+	}
+
+	if maxGenerations <= 0 || maxGenerations > MaxGenerations {
+		maxGenerations = MaxGenerations
+	}
+
+	numbers := Numbers{}
+	generation := []ancestor{{person: person, number: big.NewInt(1)}}
+
+	// Going up one generation at a time keeps the numbers of each person in
+	// increasing order.
+	for g := 0; len(generation) > 0; g++ {
+		var next []ancestor
+
+		for _, a := range generation {
+			if len(numbers[a.person.GetIndex()]) >= MaxNumbers {
+				continue
+			}
+
+			numbers[a.person.GetIndex()] = append(numbers[a.person.GetIndex()], a.number)
+
+			if g >= maxGenerations {
+				continue
+			}
+
+			if father := a.person.Father(); father != nil {
+				next = append(next, ancestor{person: father, number: new(big.Int).Lsh(a.number, 1)})
+			}
+
+			if mother := a.person.Mother(); mother != nil {
+				number := new(big.Int).Lsh(a.number, 1)
+				next = append(next, ancestor{person: mother, number: number.SetBit(number, 0, 1)})
+			}
+		}
+
+		generation = next
+	}
+
+	return numbers, nil
+}
+
+// Generation returns the generation of a Sosa number, root being generation 0.
+func Generation(number *big.Int) int {
+	return number.BitLen() - 1
+}
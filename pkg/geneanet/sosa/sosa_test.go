@@ -0,0 +1,125 @@
+package sosa
+
+import (
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/trois-six/geneparse/pkg/geneanet/api"
+	"github.com/trois-six/geneparse/pkg/geneanet/tree"
+	"github.com/trois-six/geneparse/pkg/geneanet/tree/treetest"
+	"github.com/trois-six/geneparse/pkg/geneanet/utils"
+)
+
+// cousinsTree builds a root person (8) whose parents (6, 7) are first
+// cousins: their fathers or mothers (2, 3) are the children of the same
+// couple (0, 1).
+func cousinsTree(t *testing.T) *tree.Tree {
+	t.Helper()
+
+	b := &treetest.Builder{}
+	grandfather := b.Person(api.Sex_MALE, "Louis", "Martin")
+	grandmother := b.Person(api.Sex_FEMALE, "Rose", "Petit")
+	brother := b.Person(api.Sex_MALE, "Pierre", "Martin")
+	sister := b.Person(api.Sex_FEMALE, "Jeanne", "Martin")
+	brotherWife := b.Person(api.Sex_FEMALE, "Marie", "Durand")
+	sisterHusband := b.Person(api.Sex_MALE, "Paul", "Bernard")
+	father := b.Person(api.Sex_MALE, "Jean", "Martin")
+	mother := b.Person(api.Sex_FEMALE, "Anne", "Bernard")
+	root := b.Person(api.Sex_FEMALE, "Alice", "Martin")
+
+	b.Family(grandfather, grandmother, brother, sister)
+	b.Family(brother, brotherWife, father)
+	b.Family(sisterHusband, sister, mother)
+	b.Family(father, mother, root)
+
+	return b.Tree(t)
+}
+
+func ints(numbers []*big.Int) []int64 {
+	list := []int64{}
+
+	for _, n := range numbers {
+		list = append(list, n.Int64())
+	}
+
+	return list
+}
+
+func TestCompute(t *testing.T) {
+	tr := cousinsTree(t)
+
+	for _, tc := range []struct {
+		name        string
+		root        int32
+		generations int
+		want        map[int32][]int64
+	}{
+		{
+			name: "implex",
+			root: 8,
+			want: map[int32][]int64{
+				8: {1}, 6: {2}, 7: {3}, 2: {4}, 4: {5}, 5: {6}, 3: {7},
+				0: {8, 14}, 1: {9, 15},
+			},
+		},
+		{
+			name:        "one generation",
+			root:        8,
+			generations: 1,
+			want:        map[int32][]int64{8: {1}, 6: {2}, 7: {3}},
+		},
+		{
+			name: "without implex",
+			root: 6,
+			want: map[int32][]int64{6: {1}, 2: {2}, 4: {3}, 0: {4}, 1: {5}},
+		},
+		{
+			name: "no ancestors",
+			root: 0,
+			want: map[int32][]int64{0: {1}},
+		},
+	} {
+		numbers, err := Compute(tr, tc.root, tc.generations)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got := map[int32][]int64{}
+		for i, n := range numbers {
+			got[i] = ints(n)
+		}
+
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: Compute() = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestComputeErrors(t *testing.T) {
+	b := &treetest.Builder{}
+	father := b.Person(api.Sex_MALE, "Pierre", "Martin")
+	mother := b.Person(api.Sex_FEMALE, "Marie", "Durand")
+	son := b.Person(api.Sex_MALE, "Jean", "Martin")
+	b.Family(father, mother, son)
+	b.Family(son, mother, father)
+
+	tr := b.Tree(t)
+
+	if _, err := Compute(tr, 2, 0); !errors.Is(err, utils.ErrAncestryLoop) {
+		t.Errorf("Compute() error = %v, want %v", err, utils.ErrAncestryLoop)
+	}
+
+	if _, err := Compute(tr, 3, 0); !errors.Is(err, utils.ErrIndexOutOfRange) {
+		t.Errorf("Compute() error = %v, want %v", err, utils.ErrIndexOutOfRange)
+	}
+}
+
+func TestGeneration(t *testing.T) {
+	for number, generation := range map[int64]int{1: 0, 2: 1, 3: 1, 8: 3, 15: 3, 16: 4} {
+		if got := Generation(big.NewInt(number)); got != generation {
+			t.Errorf("Generation(%d) = %d, want %d", number, got, generation)
+		}
+	}
+}
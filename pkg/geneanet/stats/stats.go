@@ -0,0 +1,343 @@
+package stats
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/trois-six/geneparse/pkg/geneanet/api"
+	"github.com/trois-six/geneparse/pkg/geneanet/sosa"
+	"github.com/trois-six/geneparse/pkg/geneanet/tree"
+	"github.com/trois-six/geneparse/pkg/geneanet/utils"
+)
+
+const (
+	// DefaultTop is the number of most common names and places reported.
+	DefaultTop = 10
+
+	yearsPerCentury = 100
+
+	// unknownName is the name given by Geneweb to the persons whose name is
+	// not known.
+	unknownName = "?"
+)
+
+// Coverage counts the records which should have a date, those which have one
+// and those whose date is sure and known to the day.
+type Coverage struct {
+	Total   int `json:"total"`
+	Dated   int `json:"dated"`
+	Precise int `json:"precise"`
+}
+
+// Count is the number of occurrences of a name or a place.
+type Count struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// Lifespan is the average age at death of the persons born in a century.
+type Lifespan struct {
+	Century int     `json:"century"`
+	Persons int     `json:"persons"`
+	Average float64 `json:"average"`
+}
+
+// Stats is the report of a base.
+type Stats struct {
+	Persons        int                 `json:"persons"`
+	Families       int                 `json:"families"`
+	BySex          map[string]int      `json:"bySex"`
+	ByDeathType    map[string]int      `json:"byDeathType"`
+	ByMarriageType map[string]int      `json:"byMarriageType"`
+	Events         map[string]int      `json:"events"`
+	DateCoverage   map[string]Coverage `json:"dateCoverage"`
+	Surnames       []Count             `json:"surnames"`
+	Firstnames     []Count             `json:"firstnames"`
+	// Places counts the persons and the families linked to each place.
+	Places []Count `json:"places"`
+	// SosaRoot is the index of the person from which GenerationDepth and
+	// Ancestors are computed.
+	SosaRoot        int32      `json:"sosaRoot"`
+	GenerationDepth int        `json:"generationDepth"`
+	Ancestors       int        `json:"ancestors"`
+	Lifespans       []Lifespan `json:"lifespans"`
+}
+
+type counter map[string]int
+
+func (c counter) add(name string) {
+	if name = strings.TrimSpace(name); name != "" && name != unknownName {
+		c[name]++
+	}
+}
+
+// top returns the n most common names, the most common first.
+func (c counter) top(n int) []Count {
+	counts := make([]Count, 0, len(c))
+
+	for name, count := range c {
+		counts = append(counts, Count{Name: name, Count: count})
+	}
+
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+
+		return counts[i].Name < counts[j].Name
+	})
+
+	if len(counts) > n {
+		counts = counts[:n]
+	}
+
+	return counts
+}
+
+func (c *Coverage) add(date *api.Date) {
+	c.Total++
+
+	if date == nil || (date.GetDmy().GetYear() == 0 && date.GetText() == "") {
+		return
+	}
+
+	c.Dated++
+
+	if date.GetPrec() == api.Precision_SURE && date.GetDmy().GetDay() != 0 {
+		c.Precise++
+	}
+}
+
+func isDead(deathType api.DeathType) bool {
+	switch deathType {
+	case api.DeathType_DEAD, api.DeathType_DEAD_YOUNG, api.DeathType_DEAD_DONT_KNOW_WHEN, api.DeathType_OF_COURSE_DEAD:
+		return true
+	case api.DeathType_NOT_DEAD, api.DeathType_DONT_KNOW_IF_DEAD:
+	}
+
+	return false
+}
+
+// familyEvent identifies a family event, which may be carried by both spouses.
+type familyEvent struct {
+	spouses [2]int32
+	name    api.EventName
+	place   string
+	date    string
+}
+
+func dateKey(date *api.Date) string {
+	dmy := date.GetDmy()
+
+	return fmt.Sprintf("%d/%d/%d-%d-%d/%s", date.GetCal(), date.GetPrec(),
+		dmy.GetYear(), dmy.GetMonth(), dmy.GetDay(), date.GetText())
+}
+
+type builder struct {
+	stats         *Stats
+	coverage      map[string]*Coverage
+	surnames      counter
+	firstnames    counter
+	places        counter
+	familyEvents  map[familyEvent]bool
+	lifespans     map[int]int
+	lifespanCount map[int]int
+}
+
+func (b *builder) coverageOf(name string) *Coverage {
+	if c, ok := b.coverage[name]; ok {
+		return c
+	}
+
+	c := &Coverage{}
+	b.coverage[name] = c
+
+	return c
+}
+
+func (b *builder) addEvent(p *tree.Person, event *api.Event) {
+	if event.GetName() >= api.EventName_EFAM_MARRIAGE {
+		spouses := [2]int32{p.GetIndex(), event.GetIndexSpouse()}
+		if spouses[0] > spouses[1] {
+			spouses[0], spouses[1] = spouses[1], spouses[0]
+		}
+
+		key := familyEvent{
+			spouses: spouses,
+			name:    event.GetName(),
+			place:   event.GetPlace(),
+			date:    dateKey(event.GetDate()),
+		}
+
+		if b.familyEvents[key] {
+			return
+		}
+
+		b.familyEvents[key] = true
+	}
+
+	b.stats.Events[event.GetName().String()]++
+}
+
+func (b *builder) addPerson(p *tree.Person) {
+	b.stats.BySex[p.GetSex().String()]++
+	b.stats.ByDeathType[p.GetDeathType().String()]++
+
+	b.surnames.add(p.GetLastname())
+
+	for _, firstname := range strings.Fields(p.GetFirstname()) {
+		b.firstnames.add(firstname)
+	}
+
+	// a place is counted once per person, though it is often both in a field
+	// and in the matching event
+	places := map[string]bool{}
+
+	for _, place := range []string{p.GetBirthPlace(), p.GetBaptismPlace(), p.GetDeathPlace(), p.GetBurialPlace()} {
+		places[place] = true
+	}
+
+	b.coverageOf("birth").add(p.GetBirthDate())
+	b.coverageOf("baptism").add(p.GetBaptismDate())
+
+	if isDead(p.GetDeathType()) {
+		b.coverageOf("death").add(p.GetDeathDate())
+		b.coverageOf("burial").add(p.GetBurialDate())
+	}
+
+	for _, event := range p.GetEvents() {
+		b.addEvent(p, event)
+
+		if event.GetName() < api.EventName_EFAM_MARRIAGE {
+			places[event.GetPlace()] = true
+		}
+	}
+
+	for place := range places {
+		b.places.add(place)
+	}
+
+	birth, okBirth := utils.DateYear(p.GetBirthDate())
+	death, okDeath := utils.DateYear(p.GetDeathDate())
+
+	if okBirth && okDeath && death >= birth {
+		century := int(birth) / yearsPerCentury * yearsPerCentury
+		b.lifespans[century] += int(death - birth)
+		b.lifespanCount[century]++
+	}
+}
+
+func (b *builder) addFamily(f *tree.Family) {
+	b.stats.ByMarriageType[f.GetMarriageType().String()]++
+	b.places.add(f.GetMarriagePlace())
+	b.coverageOf("marriage").add(f.GetMarriageDate())
+}
+
+// depth returns the number of generations above p, walking each ancestor once.
+// Persons being walked count as founders so that loops end.
+func depth(p *tree.Person, depths map[int32]int) int {
+	if p == nil {
+		return 0
+	}
+
+	if d, ok := depths[p.GetIndex()]; ok {
+		return d
+	}
+
+	depths[p.GetIndex()] = 0
+
+	d := depth(p.Father(), depths)
+	if m := depth(p.Mother(), depths); m > d {
+		d = m
+	}
+
+	if p.Father() != nil || p.Mother() != nil {
+		d++
+	}
+
+	depths[p.GetIndex()] = d
+
+	return d
+}
+
+// ancestors counts the distinct ancestors of p.
+func ancestors(p *tree.Person) int {
+	seen := map[int32]bool{p.GetIndex(): true}
+	stack := []*tree.Person{p}
+
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		for _, parent := range []*tree.Person{current.Father(), current.Mother()} {
+			if parent != nil && !seen[parent.GetIndex()] {
+				seen[parent.GetIndex()] = true
+				stack = append(stack, parent)
+			}
+		}
+	}
+
+	return len(seen) - 1
+}
+
+// Compute builds the report of the tree, listing the top most common names
+// and places.
+func Compute(t *tree.Tree, top int) *Stats {
+	b := &builder{
+		stats: &Stats{
+			Persons:        len(t.Persons()),
+			Families:       len(t.Families()),
+			BySex:          map[string]int{},
+			ByDeathType:    map[string]int{},
+			ByMarriageType: map[string]int{},
+			Events:         map[string]int{},
+			DateCoverage:   map[string]Coverage{},
+		},
+		coverage:      map[string]*Coverage{},
+		surnames:      counter{},
+		firstnames:    counter{},
+		places:        counter{},
+		familyEvents:  map[familyEvent]bool{},
+		lifespans:     map[int]int{},
+		lifespanCount: map[int]int{},
+	}
+
+	for _, p := range t.Persons() {
+		b.addPerson(p)
+	}
+
+	for _, f := range t.Families() {
+		b.addFamily(f)
+	}
+
+	for name, c := range b.coverage {
+		b.stats.DateCoverage[name] = *c
+	}
+
+	b.stats.Surnames = b.surnames.top(top)
+	b.stats.Firstnames = b.firstnames.top(top)
+	b.stats.Places = b.places.top(top)
+
+	b.stats.SosaRoot = sosa.Root(t)
+	if root := t.Person(b.stats.SosaRoot); root != nil {
+		b.stats.GenerationDepth = depth(root, map[int32]int{})
+		b.stats.Ancestors = ancestors(root)
+	}
+
+	b.stats.Lifespans = make([]Lifespan, 0, len(b.lifespans))
+
+	for century, years := range b.lifespans {
+		b.stats.Lifespans = append(b.stats.Lifespans, Lifespan{
+			Century: century,
+			Persons: b.lifespanCount[century],
+			Average: float64(years) / float64(b.lifespanCount[century]),
+		})
+	}
+
+	sort.Slice(b.stats.Lifespans, func(i, j int) bool {
+		return b.stats.Lifespans[i].Century < b.stats.Lifespans[j].Century
+	})
+
+	return b.stats
+}
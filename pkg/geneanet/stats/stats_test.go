@@ -0,0 +1,67 @@
+package stats
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/trois-six/geneparse/pkg/geneanet/api"
+	"github.com/trois-six/geneparse/pkg/geneanet/tree/treetest"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestCompute(t *testing.T) {
+	b := &treetest.Builder{}
+	root := b.Person(api.Sex_MALE, "Jean Pierre", "Martin")
+	father := b.Person(api.Sex_MALE, "Pierre", "Martin")
+	father.BirthDate, father.BirthPlace = treetest.Date(1850, 1, 1), proto.String("Paris")
+	treetest.Died(father, treetest.Date(1920, 1, 1))
+	mother := b.Person(api.Sex_FEMALE, "Marie", "Durand")
+	mother.BirthDate, mother.BirthPlace = treetest.Date(1852, 1, 1), proto.String("Paris")
+	treetest.Died(mother, treetest.Date(1900, 1, 1))
+	grandfather := b.Person(api.Sex_MALE, "Louis", "Martin")
+	grandfather.BirthDate, grandfather.BirthPlace = treetest.Date(1820, 1, 1), proto.String("Lyon")
+	grandfather.DeathPlace = proto.String("Lyon")
+	treetest.Died(grandfather, treetest.Date(1900, 1, 1))
+	grandmother := b.Person(api.Sex_FEMALE, "Rose", "Petit")
+	grandmother.BirthDate = treetest.Date(1790, 0, 0)
+	treetest.Died(grandmother, treetest.Date(1860, 0, 0))
+	b.Person(api.Sex_UNKNOWN, "?", "?")
+
+	// the marriage is an event of both spouses, counted once
+	for _, spouses := range [][2]*api.Person{{father, mother}, {mother, father}} {
+		spouses[0].Events = append(spouses[0].Events, &api.Event{
+			Name:        api.EventName_EFAM_MARRIAGE.Enum(),
+			Place:       proto.String("Paris"),
+			IndexSpouse: proto.Int32(spouses[1].GetIndex()),
+		})
+	}
+
+	b.Family(father, mother, root).MarriagePlace = proto.String("Paris")
+	b.Family(grandfather, grandmother, father)
+
+	s := Compute(b.Tree(t), 2)
+
+	for _, tc := range []struct {
+		name      string
+		got, want interface{}
+	}{
+		{"persons", s.Persons, 6},
+		{"families", s.Families, 2},
+		{"sexes", s.BySex, map[string]int{"MALE": 3, "FEMALE": 2, "UNKNOWN": 1}},
+		{"death types", s.ByDeathType, map[string]int{"NOT_DEAD": 2, "DEAD": 4}},
+		{"events", s.Events, map[string]int{"EFAM_MARRIAGE": 1}},
+		{"surnames", s.Surnames, []Count{{"Martin", 3}, {"Durand", 1}}},
+		{"first names", s.Firstnames, []Count{{"Pierre", 2}, {"Jean", 1}}},
+		{"places", s.Places, []Count{{"Paris", 3}, {"Lyon", 1}}},
+		{"birth coverage", s.DateCoverage["birth"], Coverage{Total: 6, Dated: 4, Precise: 3}},
+		{"death coverage", s.DateCoverage["death"], Coverage{Total: 4, Dated: 4, Precise: 3}},
+		{"marriage coverage", s.DateCoverage["marriage"], Coverage{Total: 2}},
+		{"generation depth", s.GenerationDepth, 2},
+		{"ancestors", s.Ancestors, 4},
+		{"lifespans", s.Lifespans, []Lifespan{{1700, 1, 70}, {1800, 3, 66}}},
+	} {
+		if !reflect.DeepEqual(tc.got, tc.want) {
+			t.Errorf("%s = %v, want %v", tc.name, tc.got, tc.want)
+		}
+	}
+}
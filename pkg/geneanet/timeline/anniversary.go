@@ -0,0 +1,163 @@
+package timeline
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/trois-six/geneparse/pkg/geneanet/api"
+	"github.com/trois-six/geneparse/pkg/geneanet/gengedcom"
+	"github.com/trois-six/geneparse/pkg/geneanet/tree"
+	"github.com/trois-six/geneparse/pkg/geneanet/utils"
+)
+
+// AllGenerations selects all the ancestors or all the descendants of the root
+// person in AnniversaryOptions.
+const AllGenerations = -1
+
+// AnniversaryOptions selects the relatives of the root person whose
+// anniversaries are listed, like the annivAsc and annivDesc settings of the
+// Geneanet account: a number of generations of 0 leaves the ancestors or the
+// descendants out, and a negative one, like AllGenerations, selects all of
+// them. The root person and their marriages are always listed.
+type AnniversaryOptions struct {
+	AncestorGenerations   int
+	DescendantGenerations int
+}
+
+// Anniversary is a birth, a marriage or a death which happened on the day
+// or in the month looked for.
+type Anniversary struct {
+	Event   string  `json:"event"`
+	Year    int32   `json:"year"`
+	Month   int32   `json:"month"`
+	Day     int32   `json:"day"`
+	Date    string  `json:"date"`
+	Place   string  `json:"place,omitempty"`
+	Persons []int32 `json:"persons"`
+	Family  *int32  `json:"family,omitempty"`
+}
+
+// anniversaryDmy returns the day and month of a date if it is sure and known
+// to the day, in the Gregorian calendar.
+func anniversaryDmy(date *api.Date) (*api.Dmy, bool) {
+	if date == nil || date.GetPrec() != api.Precision_SURE || date.GetCal() != api.Calendar_GREGORIAN {
+		return nil, false
+	}
+
+	dmy := date.GetDmy()
+
+	return dmy, dmy.GetDay() != 0 && dmy.GetMonth() != 0
+}
+
+func selectRelatives(t *tree.Tree, root int32, opts AnniversaryOptions) (*tree.Selection, error) {
+	selection := &tree.Selection{
+		Persons:  map[int32]bool{},
+		Families: map[int32]bool{},
+	}
+
+	for _, o := range []tree.SubtreeOptions{
+		{Ancestors: true, Generations: opts.AncestorGenerations},
+		{Descendants: true, Generations: opts.DescendantGenerations},
+	} {
+		switch {
+		case o.Generations == 0:
+			continue
+		case o.Generations < 0:
+			o.Generations = 0
+		}
+
+		s, err := t.Subtree(root, o)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range s.Persons {
+			selection.Persons[i] = true
+		}
+
+		for i := range s.Families {
+			selection.Families[i] = true
+		}
+	}
+
+	selection.Persons[root] = true
+
+	for _, f := range t.Person(root).Families() {
+		selection.Families[f.GetIndex()] = true
+	}
+
+	return selection, nil
+}
+
+// Anniversaries lists the births and deaths of the selected relatives of the
+// person of index root, and their marriages, which happened on the given day
+// of the month, or in the month when day is 0, ordered by day and year.
+func Anniversaries(t *tree.Tree, root int32, opts AnniversaryOptions, month, day int32) ([]Anniversary, error) {
+	if t.Person(root) == nil {
+		return nil, fmt.Errorf("%w: root person %d", utils.ErrIndexOutOfRange, root)
+	}
+
+	selection, err := selectRelatives(t, root, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	anniversaries := []Anniversary{}
+
+	add := func(name api.EventName, date *api.Date, place string, persons []int32, family *int32) {
+		dmy, ok := anniversaryDmy(date)
+		if !ok || dmy.GetMonth() != month || (day != 0 && dmy.GetDay() != day) {
+			return
+		}
+
+		anniversaries = append(anniversaries, Anniversary{
+			Event:   EventLabel(name),
+			Year:    dmy.GetYear(),
+			Month:   dmy.GetMonth(),
+			Day:     dmy.GetDay(),
+			Date:    gengedcom.DateString(date),
+			Place:   place,
+			Persons: persons,
+			Family:  family,
+		})
+	}
+
+	for i := range selection.Persons {
+		p := t.Person(i)
+		add(api.EventName_EPERS_BIRTH, p.GetBirthDate(), p.GetBirthPlace(), []int32{i}, nil)
+		add(api.EventName_EPERS_DEATH, p.GetDeathDate(), p.GetDeathPlace(), []int32{i}, nil)
+	}
+
+	for i := range selection.Families {
+		f := t.Family(i)
+
+		var spouses []int32
+
+		for _, spouse := range []*tree.Person{f.Father(), f.Mother()} {
+			if spouse != nil {
+				spouses = append(spouses, spouse.GetIndex())
+			}
+		}
+
+		if len(spouses) > 0 && f.GetMarriageType() != api.MarriageType_NOT_MARRIED {
+			add(api.EventName_EFAM_MARRIAGE, f.GetMarriageDate(), f.GetMarriagePlace(), spouses, index(i))
+		}
+	}
+
+	sort.Slice(anniversaries, func(i, j int) bool {
+		a, b := anniversaries[i], anniversaries[j]
+
+		switch {
+		case a.Day != b.Day:
+			return a.Day < b.Day
+		case a.Year != b.Year:
+			return a.Year < b.Year
+		case a.Event != b.Event:
+			return a.Event < b.Event
+		default:
+			return a.Persons[0] < b.Persons[0]
+		}
+	})
+
+	return anniversaries, nil
+}
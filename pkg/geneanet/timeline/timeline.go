@@ -0,0 +1,204 @@
+package timeline
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/trois-six/geneparse/pkg/geneanet/api"
+	"github.com/trois-six/geneparse/pkg/geneanet/gengedcom"
+	"github.com/trois-six/geneparse/pkg/geneanet/tree"
+	"github.com/trois-six/geneparse/pkg/geneanet/utils"
+)
+
+// Role is the part a person takes in an event of its timeline.
+type Role string
+
+const (
+	RolePrincipal Role = "principal"
+	RoleSpouse    Role = "spouse"
+	RoleParent    Role = "parent"
+	RoleWitness   Role = "witness"
+	RoleGodparent Role = "godparent"
+	RoleOfficer   Role = "officer"
+)
+
+// mapWitnessTypeRole maps the types of witnesses to their roles.
+var mapWitnessTypeRole = map[api.WitnessType]Role{ // nolint:gochecknoglobals
+	api.WitnessType_WITNESS:           RoleWitness,
+	api.WitnessType_WITNESS_GODPARENT: RoleGodparent,
+	api.WitnessType_WITNESS_OFFICER:   RoleOfficer,
+}
+
+// Entry is an event of the timeline of a person. Person is the other person
+// the event is about, such as the spouse, the child or the witnessed person,
+// and Family the family of family events.
+type Entry struct {
+	Event  string `json:"event"`
+	Role   Role   `json:"role"`
+	Date   string `json:"date,omitempty"`
+	Place  string `json:"place,omitempty"`
+	Person *int32 `json:"person,omitempty"`
+	Family *int32 `json:"family,omitempty"`
+
+	date *api.Date
+}
+
+// EventLabel returns a readable name of an event, such as "birth" or
+// "marriage contract".
+func EventLabel(name api.EventName) string {
+	label := strings.TrimPrefix(strings.TrimPrefix(name.String(), "EPERS_"), "EFAM_")
+
+	return strings.ToLower(strings.ReplaceAll(label, "_", " "))
+}
+
+func newEntry(event string, role Role, date *api.Date, place string) Entry {
+	return Entry{
+		Event: event,
+		Role:  role,
+		Date:  gengedcom.DateString(date),
+		Place: place,
+		date:  date,
+	}
+}
+
+func index(i int32) *int32 {
+	return &i
+}
+
+// sortKey orders the dates by year, month and day, the undated entries last.
+func sortKey(date *api.Date) [3]int32 {
+	dmy := date.GetDmy()
+	if dmy.GetYear() == 0 {
+		return [3]int32{math.MaxInt32}
+	}
+
+	return [3]int32{dmy.GetYear(), dmy.GetMonth(), dmy.GetDay()}
+}
+
+func sortEntries(entries []Entry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := sortKey(entries[i].date), sortKey(entries[j].date)
+
+		for k := range a {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+
+		return false
+	})
+}
+
+// personalEntries returns the events of p, falling back to its birth and death
+// fields when they are not events.
+func personalEntries(p *tree.Person) []Entry {
+	var (
+		entries         []Entry
+		birth, death    bool
+		familyEventsFor = map[int32]bool{}
+	)
+
+	for _, event := range p.GetEvents() {
+		entry := newEntry(EventLabel(event.GetName()), RolePrincipal, event.GetDate(), event.GetPlace())
+
+		switch name := event.GetName(); {
+		case name == api.EventName_EPERS_BIRTH:
+			birth = true
+		case name == api.EventName_EPERS_DEATH:
+			death = true
+		case name >= api.EventName_EFAM_MARRIAGE:
+			entry.Role = RoleSpouse
+			entry.Person = index(event.GetIndexSpouse())
+			familyEventsFor[event.GetIndexSpouse()] = true
+		}
+
+		entries = append(entries, entry)
+	}
+
+	if !birth && p.GetBirthDate() != nil {
+		entries = append(entries, newEntry(EventLabel(api.EventName_EPERS_BIRTH), RolePrincipal,
+			p.GetBirthDate(), p.GetBirthPlace()))
+	}
+
+	if !death && p.GetDeathDate() != nil {
+		entries = append(entries, newEntry(EventLabel(api.EventName_EPERS_DEATH), RolePrincipal,
+			p.GetDeathDate(), p.GetDeathPlace()))
+	}
+
+	for _, family := range p.Families() {
+		spouse := family.Spouse(p)
+
+		if family.GetMarriageDate() != nil && (spouse == nil || !familyEventsFor[spouse.GetIndex()]) {
+			entry := newEntry(EventLabel(api.EventName_EFAM_MARRIAGE), RoleSpouse,
+				family.GetMarriageDate(), family.GetMarriagePlace())
+			entry.Family = index(family.GetIndex())
+
+			if spouse != nil {
+				entry.Person = index(spouse.GetIndex())
+			}
+
+			entries = append(entries, entry)
+		}
+
+		for _, child := range family.Children() {
+			entry := newEntry(EventLabel(api.EventName_EPERS_BIRTH), RoleParent, child.GetBirthDate(), child.GetBirthPlace())
+			entry.Person = index(child.GetIndex())
+			entry.Family = index(family.GetIndex())
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries
+}
+
+// witnessedEntries returns the events of the other persons and the marriages
+// p was a witness of.
+func witnessedEntries(t *tree.Tree, p *tree.Person) []Entry {
+	var entries []Entry
+
+	for _, other := range t.Persons() {
+		for _, event := range other.GetEvents() {
+			for _, witness := range event.GetWitnesses() {
+				if witness.GetWitness() != p.GetIndex() {
+					continue
+				}
+
+				entry := newEntry(EventLabel(event.GetName()), mapWitnessTypeRole[witness.GetWitnessType()],
+					event.GetDate(), event.GetPlace())
+				entry.Person = index(other.GetIndex())
+				entries = append(entries, entry)
+			}
+		}
+	}
+
+	for _, family := range t.Families() {
+		for _, witness := range family.GetWitnesses() {
+			if witness == p.GetIndex() {
+				entry := newEntry(EventLabel(api.EventName_EFAM_MARRIAGE), RoleWitness,
+					family.GetMarriageDate(), family.GetMarriagePlace())
+				entry.Family = index(family.GetIndex())
+				entries = append(entries, entry)
+			}
+		}
+	}
+
+	return entries
+}
+
+// Timeline returns the events of the life of the person of index i in
+// chronological order: its own events, the events of its families, including
+// the births of its children, and the events it was a witness of.
+func Timeline(t *tree.Tree, i int32) ([]Entry, error) {
+	p := t.Person(i)
+	if p == nil {
+		return nil, fmt.Errorf("%w: person %d", utils.ErrIndexOutOfRange, i)
+	}
+
+	entries := append(personalEntries(p), witnessedEntries(t, p)...)
+
+	sortEntries(entries)
+
+	return entries, nil
+}
@@ -0,0 +1,169 @@
+package timeline
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/trois-six/geneparse/pkg/geneanet/api"
+	"github.com/trois-six/geneparse/pkg/geneanet/tree"
+	"github.com/trois-six/geneparse/pkg/geneanet/tree/treetest"
+	"github.com/trois-six/geneparse/pkg/geneanet/utils"
+	"google.golang.org/protobuf/proto"
+)
+
+// testTree builds three generations: a grandfather (0) and a grandmother (1)
+// have a son (2), who marries (3) and has a son (4), who marries (5) and has a
+// daughter (6). The grandfather is the godfather of his grandson and a
+// witness of his marriage.
+func testTree(t *testing.T) *tree.Tree {
+	t.Helper()
+
+	b := &treetest.Builder{}
+	grandfather := b.Person(api.Sex_MALE, "Louis", "Martin")
+	grandfather.BirthDate = treetest.Date(1820, 3, 15)
+	grandmother := b.Person(api.Sex_FEMALE, "Rose", "Petit")
+	father := b.Person(api.Sex_MALE, "Pierre", "Martin")
+	father.BirthDate, father.BirthPlace = treetest.Date(1850, 3, 15), proto.String("Paris")
+	treetest.Died(father, treetest.Date(1920, 7, 1))
+	mother := b.Person(api.Sex_FEMALE, "Marie", "Durand")
+	mother.BirthDate = treetest.Date(1852, 5, 2)
+	son := b.Person(api.Sex_MALE, "Jean", "Martin")
+	son.BirthDate = treetest.Date(1880, 3, 15)
+	son.Events = []*api.Event{{
+		Name: api.EventName_EPERS_BAPTISM.Enum(),
+		Date: treetest.Date(1880, 3, 16),
+		Witnesses: []*api.WitnessEvent{{
+			WitnessType: api.WitnessType_WITNESS_GODPARENT.Enum(),
+			Witness:     proto.Int32(grandfather.GetIndex()),
+		}},
+	}}
+	wife := b.Person(api.Sex_FEMALE, "Anne", "Leroy")
+	granddaughter := b.Person(api.Sex_FEMALE, "Alice", "Martin")
+	granddaughter.BirthDate = treetest.Date(1910, 8, 9)
+
+	b.Family(grandfather, grandmother, father)
+	b.Family(father, mother, son).MarriageDate = treetest.Date(1875, 3, 20)
+	f := b.Family(son, wife, granddaughter)
+	f.MarriageDate = treetest.Date(1905, 3, 15)
+	f.Witnesses = []int32{grandfather.GetIndex()}
+
+	return b.Tree(t)
+}
+
+func TestTimeline(t *testing.T) {
+	tr := testTree(t)
+
+	type entry struct {
+		event  string
+		role   Role
+		date   string
+		person int32
+	}
+
+	for _, tc := range []struct {
+		person int32
+		want   []entry
+	}{
+		{
+			person: 2,
+			want: []entry{
+				{"birth", RolePrincipal, "15 MAR 1850", -1},
+				{"marriage", RoleSpouse, "20 MAR 1875", 3},
+				{"birth", RoleParent, "15 MAR 1880", 4},
+				{"death", RolePrincipal, "1 JUL 1920", -1},
+			},
+		},
+		{
+			person: 0,
+			want: []entry{
+				{"birth", RolePrincipal, "15 MAR 1820", -1},
+				{"birth", RoleParent, "15 MAR 1850", 2},
+				{"baptism", RoleGodparent, "16 MAR 1880", 4},
+				{"marriage", RoleWitness, "15 MAR 1905", -1},
+			},
+		},
+	} {
+		entries, err := Timeline(tr, tc.person)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got := []entry{}
+
+		for _, e := range entries {
+			person := int32(-1)
+			if e.Person != nil {
+				person = *e.Person
+			}
+
+			got = append(got, entry{e.Event, e.Role, e.Date, person})
+		}
+
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("Timeline(%d) = %v, want %v", tc.person, got, tc.want)
+		}
+	}
+
+	if _, err := Timeline(tr, 7); !errors.Is(err, utils.ErrIndexOutOfRange) {
+		t.Errorf("Timeline() error = %v, want %v", err, utils.ErrIndexOutOfRange)
+	}
+}
+
+func TestAnniversaries(t *testing.T) {
+	tr := testTree(t)
+
+	for _, tc := range []struct {
+		name       string
+		opts       AnniversaryOptions
+		month, day int32
+		want       []string
+	}{
+		{
+			name:  "root only",
+			month: 3,
+			want:  []string{"birth 1850", "marriage 1875"},
+		},
+		{
+			name:  "one generation of descendants",
+			opts:  AnniversaryOptions{DescendantGenerations: 1},
+			month: 3,
+			day:   15,
+			want:  []string{"birth 1850", "birth 1880", "marriage 1905"},
+		},
+		{
+			name:  "all relatives",
+			opts:  AnniversaryOptions{AncestorGenerations: AllGenerations, DescendantGenerations: AllGenerations},
+			month: 3,
+			want:  []string{"birth 1820", "birth 1850", "birth 1880", "marriage 1905", "marriage 1875"},
+		},
+		{
+			name:  "all descendants in august",
+			opts:  AnniversaryOptions{DescendantGenerations: AllGenerations},
+			month: 8,
+			want:  []string{"birth 1910"},
+		},
+		{
+			name:  "nothing on the day",
+			opts:  AnniversaryOptions{AncestorGenerations: AllGenerations},
+			month: 3,
+			day:   16,
+			want:  []string{},
+		},
+	} {
+		anniversaries, err := Anniversaries(tr, 2, tc.opts, tc.month, tc.day)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got := []string{}
+		for _, a := range anniversaries {
+			got = append(got, fmt.Sprintf("%s %d", a.Event, a.Year))
+		}
+
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: Anniversaries() = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
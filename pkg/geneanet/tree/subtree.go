@@ -0,0 +1,98 @@
+package tree
+
+import (
+	"fmt"
+
+	"github.com/trois-six/geneparse/pkg/geneanet/utils"
+)
+
+// SubtreeOptions tells which branch of the tree Subtree selects around its
+// root person.
+type SubtreeOptions struct {
+	// Ancestors and Descendants select the ancestors and the descendants of
+	// the root person.
+	Ancestors   bool
+	Descendants bool
+	// Generations limits the number of generations selected above and below
+	// the root person, 0 meaning all of them.
+	Generations int
+	// Spouses and Siblings add the spouses and the siblings of the selected
+	// persons.
+	Spouses  bool
+	Siblings bool
+}
+
+// Selection is a set of persons and the families they are a spouse in, by
+// index.
+type Selection struct {
+	Persons  map[int32]bool
+	Families map[int32]bool
+}
+
+func (t *Tree) walk(root *Person, generations int, next func(*Person) []*Person, selected map[int32]bool) {
+	generation := []*Person{root}
+
+	for g := 1; len(generation) > 0 && (generations == 0 || g <= generations); g++ {
+		var persons []*Person
+
+		for _, person := range generation {
+			for _, p := range next(person) {
+				if p != nil && !selected[p.GetIndex()] {
+					selected[p.GetIndex()] = true
+					persons = append(persons, p)
+				}
+			}
+		}
+
+		generation = persons
+	}
+}
+
+// Subtree selects the branch of the tree around the person of index root.
+func (t *Tree) Subtree(root int32, opts SubtreeOptions) (*Selection, error) {
+	person := t.Person(root)
+	if person == nil {
+		return nil, fmt.Errorf("%w: root person %d", utils.ErrIndexOutOfRange, root)
+	}
+
+	persons := map[int32]bool{root: true}
+
+	if opts.Ancestors {
+		t.walk(person, opts.Generations, func(p *Person) []*Person {
+			return []*Person{p.Father(), p.Mother()}
+		}, persons)
+	}
+
+	if opts.Descendants {
+		t.walk(person, opts.Generations, (*Person).Children, persons)
+	}
+
+	var relatives []*Person
+
+	for index := range persons {
+		p := t.Person(index)
+
+		if opts.Spouses {
+			relatives = append(relatives, p.Spouses()...)
+		}
+
+		if opts.Siblings {
+			relatives = append(relatives, p.Siblings()...)
+			relatives = append(relatives, p.HalfSiblings()...)
+		}
+	}
+
+	for _, p := range relatives {
+		persons[p.GetIndex()] = true
+	}
+
+	families := map[int32]bool{}
+
+	for index := range persons {
+		for _, family := range t.Person(index).GetFamilies() {
+			families[family] = true
+		}
+	}
+
+	return &Selection{Persons: persons, Families: families}, nil
+}
@@ -0,0 +1,322 @@
+package tree
+
+import (
+	"fmt"
+
+	"github.com/trois-six/geneparse/pkg/geneanet/api"
+	"github.com/trois-six/geneparse/pkg/geneanet/database"
+	"github.com/trois-six/geneparse/pkg/geneanet/utils"
+)
+
+// Tree is a navigable genealogy graph built from the persons and families of
+// a base, linked together through their indexes.
+type Tree struct {
+	info     *database.BaseInfo
+	persons  []*Person
+	families []*Family
+}
+
+// Person is an api.Person which can navigate to its relatives.
+type Person struct {
+	*api.Person
+	tree *Tree
+	note string
+}
+
+// Family is an api.Family which can navigate to its members.
+type Family struct {
+	*api.Family
+	tree *Tree
+	note string
+}
+
+func checkIndex(kind string, owner, index int32, length int) error {
+	if index < 0 || int(index) >= length {
+		return fmt.Errorf("%w: %s %d refers to %d", utils.ErrDanglingIndex, kind, owner, index)
+	}
+
+	return nil
+}
+
+func (t *Tree) validate() error { //nolint:cyclop
+	for k, person := range t.persons {
+		if int(person.GetIndex()) != k {
+			return fmt.Errorf("%w: person %d stored at %d", utils.ErrIndexMismatch, person.GetIndex(), k)
+		}
+
+		if person.Person.Parents != nil {
+			if err := checkIndex("parents of person", person.GetIndex(), person.GetParents(), len(t.families)); err != nil {
+				return err
+			}
+		}
+
+		for _, family := range person.GetFamilies() {
+			if err := checkIndex("family of person", person.GetIndex(), family, len(t.families)); err != nil {
+				return err
+			}
+		}
+	}
+
+	for k, family := range t.families {
+		if int(family.GetIndex()) != k {
+			return fmt.Errorf("%w: family %d stored at %d", utils.ErrIndexMismatch, family.GetIndex(), k)
+		}
+
+		if err := checkIndex("father of family", family.GetIndex(), family.GetFather(), len(t.persons)); err != nil {
+			return err
+		}
+
+		if err := checkIndex("mother of family", family.GetIndex(), family.GetMother(), len(t.persons)); err != nil {
+			return err
+		}
+
+		for _, child := range family.GetChildren() {
+			if err := checkIndex("child of family", family.GetIndex(), child, len(t.persons)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// New builds a tree from persons and families, notes being optional. It fails
+// if a person or a family refers to an index which does not exist.
+func New(
+	info *database.BaseInfo,
+	persons []*api.Person,
+	families []*api.Family,
+	personsNotes, familiesNotes []string) (*Tree, error) {
+	t := &Tree{
+		info:     info,
+		persons:  make([]*Person, len(persons)),
+		families: make([]*Family, len(families)),
+	}
+
+	for k, person := range persons {
+		t.persons[k] = &Person{Person: person, tree: t}
+
+		if k < len(personsNotes) {
+			t.persons[k].note = personsNotes[k]
+		}
+	}
+
+	for k, family := range families {
+		t.families[k] = &Family{Family: family, tree: t}
+
+		if k < len(familiesNotes) {
+			t.families[k].note = familiesNotes[k]
+		}
+	}
+
+	if err := t.validate(); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// Load reads the base found in path and builds its tree.
+func Load(path string) (*Tree, error) {
+	info, err := database.ReadInfoBase(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read base info: %w", err)
+	}
+
+	person := database.NewPerson(path)
+	family := database.NewFamily(path)
+
+	if err = database.PopulateDatabases([]database.Database{person, family}); err != nil {
+		return nil, fmt.Errorf("databases populate failed: %w", err)
+	}
+
+	return New(info, person.GetPersons(), family.GetFamilies(), person.GetRawNotes(), family.GetRawNotes())
+}
+
+// Info returns the base info the tree was built with, if any.
+func (t *Tree) Info() *database.BaseInfo {
+	return t.info
+}
+
+// Persons returns all the persons, ordered by index.
+func (t *Tree) Persons() []*Person {
+	return t.persons
+}
+
+// Families returns all the families, ordered by index.
+func (t *Tree) Families() []*Family {
+	return t.families
+}
+
+// Person returns the person of index i, or nil if there is none.
+func (t *Tree) Person(i int32) *Person {
+	if i < 0 || int(i) >= len(t.persons) {
+		return nil
+	}
+
+	return t.persons[i]
+}
+
+// Family returns the family of index i, or nil if there is none.
+func (t *Tree) Family(i int32) *Family {
+	if i < 0 || int(i) >= len(t.families) {
+		return nil
+	}
+
+	return t.families[i]
+}
+
+// Note returns the note of the person, empty if it has none.
+func (p *Person) Note() string {
+	if p == nil {
+		return ""
+	}
+
+	return p.note
+}
+
+// Parents returns the family the person is a child of, or nil.
+func (p *Person) Parents() *Family {
+	if p == nil || p.Person.Parents == nil {
+		return nil
+	}
+
+	return p.tree.Family(p.GetParents())
+}
+
+// Father returns the father of the person, or nil.
+func (p *Person) Father() *Person {
+	return p.Parents().Father()
+}
+
+// Mother returns the mother of the person, or nil.
+func (p *Person) Mother() *Person {
+	return p.Parents().Mother()
+}
+
+// Families returns the families the person is a spouse in.
+func (p *Person) Families() []*Family {
+	if p == nil {
+		return nil
+	}
+
+	families := make([]*Family, 0, len(p.GetFamilies()))
+
+	for _, family := range p.GetFamilies() {
+		families = append(families, p.tree.Family(family))
+	}
+
+	return families
+}
+
+// Spouses returns the spouses of the person, in the order of its families.
+func (p *Person) Spouses() []*Person {
+	spouses := make([]*Person, 0, len(p.GetFamilies()))
+
+	for _, family := range p.Families() {
+		if spouse := family.Spouse(p); spouse != nil {
+			spouses = append(spouses, spouse)
+		}
+	}
+
+	return spouses
+}
+
+// Children returns the children of the person, from all its families.
+func (p *Person) Children() []*Person {
+	var children []*Person
+
+	for _, family := range p.Families() {
+		children = append(children, family.Children()...)
+	}
+
+	return children
+}
+
+// Siblings returns the other children of the parents of the person.
+func (p *Person) Siblings() []*Person {
+	var siblings []*Person
+
+	for _, child := range p.Parents().Children() {
+		if child != p {
+			siblings = append(siblings, child)
+		}
+	}
+
+	return siblings
+}
+
+// HalfSiblings returns the children the father or the mother of the person had
+// in their other families.
+func (p *Person) HalfSiblings() []*Person {
+	parents := p.Parents()
+	if parents == nil {
+		return nil
+	}
+
+	var halfSiblings []*Person
+
+	for _, parent := range []*Person{parents.Father(), parents.Mother()} {
+		for _, family := range parent.Families() {
+			if family != parents {
+				halfSiblings = append(halfSiblings, family.Children()...)
+			}
+		}
+	}
+
+	return halfSiblings
+}
+
+// Note returns the note of the family, empty if it has none.
+func (f *Family) Note() string {
+	if f == nil {
+		return ""
+	}
+
+	return f.note
+}
+
+// Father returns the father of the family, or nil.
+func (f *Family) Father() *Person {
+	if f == nil {
+		return nil
+	}
+
+	return f.tree.Person(f.GetFather())
+}
+
+// Mother returns the mother of the family, or nil.
+func (f *Family) Mother() *Person {
+	if f == nil {
+		return nil
+	}
+
+	return f.tree.Person(f.GetMother())
+}
+
+// Spouse returns the other spouse of the family than p, or nil.
+func (f *Family) Spouse(p *Person) *Person {
+	switch p {
+	case f.Father():
+		return f.Mother()
+	case f.Mother():
+		return f.Father()
+	default:
+		return nil
+	}
+}
+
+// Children returns the children of the family.
+func (f *Family) Children() []*Person {
+	if f == nil {
+		return nil
+	}
+
+	children := make([]*Person, 0, len(f.GetChildren()))
+
+	for _, child := range f.GetChildren() {
+		children = append(children, f.tree.Person(child))
+	}
+
+	return children
+}
@@ -0,0 +1,150 @@
+package tree_test
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/trois-six/geneparse/pkg/geneanet/api"
+	"github.com/trois-six/geneparse/pkg/geneanet/database"
+	"github.com/trois-six/geneparse/pkg/geneanet/tree"
+	"github.com/trois-six/geneparse/pkg/geneanet/tree/treetest"
+	"github.com/trois-six/geneparse/pkg/geneanet/utils"
+	"google.golang.org/protobuf/proto"
+)
+
+// testTree builds three generations: a grandfather (0) and a grandmother (1)
+// have a son (2), who has two children (4, 5) with a first wife (3) and one
+// (7) with a second wife (6). The first child has a daughter (9) with his
+// wife (8).
+func testTree(t *testing.T) *tree.Tree {
+	t.Helper()
+
+	b := &treetest.Builder{}
+	grandfather := b.Person(api.Sex_MALE, "Louis", "Martin")
+	grandmother := b.Person(api.Sex_FEMALE, "Rose", "Petit")
+	father := b.Person(api.Sex_MALE, "Pierre", "Martin")
+	firstWife := b.Person(api.Sex_FEMALE, "Marie", "Durand")
+	son := b.Person(api.Sex_MALE, "Jean", "Martin")
+	daughter := b.Person(api.Sex_FEMALE, "Jeanne", "Martin")
+	secondWife := b.Person(api.Sex_FEMALE, "Louise", "Bernard")
+	halfBrother := b.Person(api.Sex_MALE, "Paul", "Martin")
+	daughterInLaw := b.Person(api.Sex_FEMALE, "Anne", "Leroy")
+	granddaughter := b.Person(api.Sex_FEMALE, "Alice", "Martin")
+
+	b.Family(grandfather, grandmother, father)
+	b.Family(father, firstWife, son, daughter)
+	b.Family(father, secondWife, halfBrother)
+	b.Family(son, daughterInLaw, granddaughter)
+
+	return b.Tree(t)
+}
+
+func indexes(persons []*tree.Person) []int32 {
+	list := []int32{}
+
+	for _, p := range persons {
+		list = append(list, p.GetIndex())
+	}
+
+	return list
+}
+
+func sorted(set map[int32]bool) []int32 {
+	list := []int32{}
+
+	for i := range set {
+		list = append(list, i)
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i] < list[j] })
+
+	return list
+}
+
+func TestNewDanglingIndex(t *testing.T) {
+	persons := []*api.Person{{
+		Index:     proto.Int32(0),
+		Sex:       api.Sex_MALE.Enum(),
+		Lastname:  proto.String("Martin"),
+		Firstname: proto.String("Pierre"),
+		Occ:       proto.Int32(0),
+		DeathType: api.DeathType_NOT_DEAD.Enum(),
+		Parents:   proto.Int32(1),
+	}}
+
+	if _, err := tree.New(&database.BaseInfo{}, persons, nil, nil, nil); !errors.Is(err, utils.ErrDanglingIndex) {
+		t.Errorf("New() error = %v, want %v", err, utils.ErrDanglingIndex)
+	}
+}
+
+func TestRelatives(t *testing.T) {
+	tr := testTree(t)
+
+	for _, tc := range []struct {
+		name string
+		got  []*tree.Person
+		want []int32
+	}{
+		{"parents", []*tree.Person{tr.Person(4).Father(), tr.Person(4).Mother()}, []int32{2, 3}},
+		{"spouses", tr.Person(2).Spouses(), []int32{3, 6}},
+		{"children", tr.Person(2).Children(), []int32{4, 5, 7}},
+		{"siblings", tr.Person(4).Siblings(), []int32{5}},
+		{"half-siblings", tr.Person(4).HalfSiblings(), []int32{7}},
+		{"no siblings", tr.Person(0).Siblings(), []int32{}},
+	} {
+		if got := indexes(tc.got); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+
+	if tr.Person(0).Parents() != nil || tr.Person(0).Father() != nil || tr.Person(10) != nil { //nolint:gomnd
+		t.Error("missing relatives are not nil")
+	}
+}
+
+func TestSubtree(t *testing.T) {
+	tr := testTree(t)
+
+	for _, tc := range []struct {
+		name     string
+		root     int32
+		opts     tree.SubtreeOptions
+		persons  []int32
+		families []int32
+	}{
+		{"root only", 4, tree.SubtreeOptions{}, []int32{4}, []int32{3}},
+		{"ancestors", 4, tree.SubtreeOptions{Ancestors: true}, []int32{0, 1, 2, 3, 4}, []int32{0, 1, 2, 3}},
+		{
+			"one generation of ancestors", 4, tree.SubtreeOptions{Ancestors: true, Generations: 1},
+			[]int32{2, 3, 4}, []int32{1, 2, 3},
+		},
+		{"descendants", 2, tree.SubtreeOptions{Descendants: true}, []int32{2, 4, 5, 7, 9}, []int32{1, 2, 3}},
+		{
+			"descendants with spouses", 2, tree.SubtreeOptions{Descendants: true, Spouses: true},
+			[]int32{2, 3, 4, 5, 6, 7, 8, 9}, []int32{1, 2, 3},
+		},
+		{
+			"siblings", 4, tree.SubtreeOptions{Siblings: true},
+			[]int32{4, 5, 7}, []int32{3},
+		},
+	} {
+		s, err := tr.Subtree(tc.root, tc.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := sorted(s.Persons); !reflect.DeepEqual(got, tc.persons) {
+			t.Errorf("%s: persons = %v, want %v", tc.name, got, tc.persons)
+		}
+
+		if got := sorted(s.Families); !reflect.DeepEqual(got, tc.families) {
+			t.Errorf("%s: families = %v, want %v", tc.name, got, tc.families)
+		}
+	}
+
+	if _, err := tr.Subtree(42, tree.SubtreeOptions{}); !errors.Is(err, utils.ErrIndexOutOfRange) { //nolint:gomnd
+		t.Errorf("Subtree() error = %v, want %v", err, utils.ErrIndexOutOfRange)
+	}
+}
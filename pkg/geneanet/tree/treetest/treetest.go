@@ -0,0 +1,121 @@
+// Package treetest builds small trees for the tests of the packages working
+// on trees.
+package treetest
+
+import (
+	"testing"
+
+	"github.com/trois-six/geneparse/pkg/geneanet/api"
+	"github.com/trois-six/geneparse/pkg/geneanet/database"
+	"github.com/trois-six/geneparse/pkg/geneanet/tree"
+	"google.golang.org/protobuf/proto"
+)
+
+// Builder adds persons and families one by one, keeping their indexes and
+// links consistent.
+type Builder struct {
+	persons  []*api.Person
+	families []*api.Family
+}
+
+// Person adds a living person and returns it, so that its dates and places
+// can be set.
+func (b *Builder) Person(sex api.Sex, firstname, lastname string) *api.Person {
+	p := &api.Person{
+		Index:     proto.Int32(int32(len(b.persons))),
+		Sex:       sex.Enum(),
+		Lastname:  proto.String(lastname),
+		Firstname: proto.String(firstname),
+		Occ:       proto.Int32(0),
+		DeathType: api.DeathType_NOT_DEAD.Enum(),
+	}
+
+	b.persons = append(b.persons, p)
+
+	return p
+}
+
+// Family adds the married family of father and mother, setting it as the
+// parents of the children, and returns it.
+func (b *Builder) Family(father, mother *api.Person, children ...*api.Person) *api.Family {
+	f := &api.Family{
+		Index:        proto.Int32(int32(len(b.families))),
+		MarriageType: api.MarriageType_MARRIED.Enum(),
+		DivorceType:  api.DivorceType_NOT_DIVORCED.Enum(),
+		Father:       proto.Int32(father.GetIndex()),
+		Mother:       proto.Int32(mother.GetIndex()),
+	}
+
+	father.Families = append(father.Families, f.GetIndex())
+	mother.Families = append(mother.Families, f.GetIndex())
+
+	for _, child := range children {
+		f.Children = append(f.Children, child.GetIndex())
+		child.Parents = proto.Int32(f.GetIndex())
+	}
+
+	b.families = append(b.families, f)
+
+	return f
+}
+
+// Persons returns the persons added so far.
+func (b *Builder) Persons() []*api.Person {
+	return b.persons
+}
+
+// Families returns the families added so far.
+func (b *Builder) Families() []*api.Family {
+	return b.families
+}
+
+// Tree builds the tree, failing the test if it is not valid.
+func (b *Builder) Tree(tb testing.TB) *tree.Tree {
+	tb.Helper()
+
+	t, err := tree.New(&database.BaseInfo{}, b.persons, b.families, nil, nil)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	return t
+}
+
+// Write writes the base into dir, failing the test on errors.
+func (b *Builder) Write(tb testing.TB, dir string) {
+	tb.Helper()
+
+	person, family := database.NewPerson(dir), database.NewFamily(dir)
+	person.SetPersons(b.persons)
+	person.SetNotes(make([]string, len(b.persons)))
+	family.SetFamilies(b.families)
+	family.SetNotes(make([]string, len(b.families)))
+
+	if err := database.WriteDatabases([]database.Database{person, family}); err != nil {
+		tb.Fatal(err)
+	}
+
+	if err := database.WriteInfoBase(dir, &database.BaseInfo{NbPersons: uint32(len(b.persons))}); err != nil {
+		tb.Fatal(err)
+	}
+}
+
+// Date returns a sure Gregorian date.
+func Date(year, month, day int32) *api.Date {
+	return &api.Date{
+		Cal:  api.Calendar_GREGORIAN.Enum(),
+		Prec: api.Precision_SURE.Enum(),
+		Dmy: &api.Dmy{
+			Day:   proto.Int32(day),
+			Month: proto.Int32(month),
+			Year:  proto.Int32(year),
+			Delta: proto.Int32(0),
+		},
+	}
+}
+
+// Died marks the person dead on the given date.
+func Died(p *api.Person, date *api.Date) {
+	p.DeathType = api.DeathType_DEAD.Enum()
+	p.DeathDate = date
+}
@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"github.com/trois-six/geneparse/pkg/geneanet/api"
+)
+
+// exactDmy returns the day, month and year of a date which is not a range nor
+// a bound, and which uses a calendar comparable to the Gregorian one.
+func exactDmy(date *api.Date) (*api.Dmy, bool) {
+	if date == nil || date.Dmy == nil || date.GetDmy().GetYear() == 0 {
+		return nil, false
+	}
+
+	switch date.GetPrec() {
+	case api.Precision_BEFORE, api.Precision_AFTER, api.Precision_ORYEAR, api.Precision_YEARINT:
+		return nil, false
+	case api.Precision_SURE, api.Precision_ABOUT, api.Precision_MAYBE:
+	}
+
+	switch date.GetCal() {
+	case api.Calendar_FRENCH, api.Calendar_HEBREW:
+		return nil, false
+	case api.Calendar_GREGORIAN, api.Calendar_JULIAN:
+	}
+
+	return date.GetDmy(), true
+}
+
+// DateYear returns the year of a date, if it is known precisely enough to be
+// compared.
+func DateYear(date *api.Date) (int32, bool) {
+	dmy, ok := exactDmy(date)
+	if !ok {
+		return 0, false
+	}
+
+	return dmy.GetYear(), true
+}
+
+func compareInt32(a, b int32) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// CompareDates compares two dates down to the most precise part they both
+// know: it returns -1, 0 or 1 as a is before, the same as or after b, and false
+// when they cannot be compared.
+func CompareDates(a, b *api.Date) (int, bool) {
+	dmyA, okA := exactDmy(a)
+	dmyB, okB := exactDmy(b)
+
+	if !okA || !okB {
+		return 0, false
+	}
+
+	if c := compareInt32(dmyA.GetYear(), dmyB.GetYear()); c != 0 {
+		return c, true
+	}
+
+	if dmyA.GetMonth() == 0 || dmyB.GetMonth() == 0 {
+		return 0, true
+	}
+
+	if c := compareInt32(dmyA.GetMonth(), dmyB.GetMonth()); c != 0 {
+		return c, true
+	}
+
+	if dmyA.GetDay() == 0 || dmyB.GetDay() == 0 {
+		return 0, true
+	}
+
+	return compareInt32(dmyA.GetDay(), dmyB.GetDay()), true
+}
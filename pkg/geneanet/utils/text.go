@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Normalize folds accents and case and keeps only letters, separated by a
+// single space: "Jean-Étienne  d'Aubigné" gives "jean etienne d aubigne".
+func Normalize(s string) string {
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+
+	folded, _, err := transform.String(t, s)
+	if err != nil {
+		folded = s
+	}
+
+	folded = strings.Map(func(r rune) rune {
+		switch {
+		case r == 'ß':
+			return 's'
+		case unicode.IsLetter(r):
+			return unicode.ToLower(r)
+		default:
+			return ' '
+		}
+	}, folded)
+
+	return strings.Join(strings.Fields(folded), " ")
+}
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"bufio"
 	"encoding/binary"
 	"errors"
 	"fmt"
@@ -14,6 +15,7 @@ import (
 
 const (
 	ErrRead       = "could not read file: %w"
+	ErrWrite      = "could not write file: %w"
 	ErrParseInput = "could not parse input: %w"
 
 	ConstUint32Bytes = 4
@@ -26,6 +28,15 @@ var (
 	ErrFileMalFormatted = errors.New("file malformatted")
 	ErrDirDoesNotExist  = errors.New("directory does not exist")
 	ErrDirMustBeADir    = errors.New("directory must be a directory")
+	ErrIndexOutOfRange  = errors.New("index out of range")
+	ErrNotOpened        = errors.New("database not opened")
+	ErrDanglingIndex    = errors.New("dangling index")
+	ErrIndexMismatch    = errors.New("index does not match position")
+	ErrAncestryLoop     = errors.New("person is its own ancestor")
+	ErrInvalidQuery     = errors.New("invalid search query")
+	ErrNoSnapshot       = errors.New("no snapshot")
+	ErrUnknownSnapshot  = errors.New("unknown snapshot")
+	ErrNoCredentials    = errors.New("no saved session and no username and password to log in")
 )
 
 func FileExists(f string) bool {
@@ -53,6 +64,20 @@ func ReadBytes(r io.Reader) (uint32, []byte, error) {
 	return ConstUint32Bytes + size, data, nil
 }
 
+// WriteBytes writes data prefixed by its length, as read by ReadBytes, and
+// returns the number of bytes written.
+func WriteBytes(w io.Writer, data []byte) (uint32, error) {
+	if err := binary.Write(w, binary.BigEndian, uint32(len(data))); err != nil {
+		return 0, fmt.Errorf(ErrWrite, err)
+	}
+
+	if _, err := w.Write(data); err != nil {
+		return ConstUint32Bytes, fmt.Errorf(ErrWrite, err)
+	}
+
+	return ConstUint32Bytes + uint32(len(data)), nil
+}
+
 func PointerStr(prefix string, id int32) string {
 	return prefix + strconv.FormatInt(int64(id), ConstDecBase)
 }
@@ -83,6 +108,32 @@ func ReadIdx(fileFullPath string) (idx []uint32, err error) {
 	return idx, nil
 }
 
+// WriteIdx writes an index file as read by ReadIdx.
+func WriteIdx(fileFullPath string, idx []uint32) error {
+	fi, err := os.Create(fileFullPath)
+	if err != nil {
+		return fmt.Errorf("failed creating database index file: %w", err)
+	}
+
+	defer fi.Close()
+
+	w := bufio.NewWriter(fi)
+
+	if err = binary.Write(w, binary.BigEndian, idx); err != nil {
+		return fmt.Errorf(ErrWrite, err)
+	}
+
+	if err = w.Flush(); err != nil {
+		return fmt.Errorf(ErrWrite, err)
+	}
+
+	if err = fi.Close(); err != nil {
+		return fmt.Errorf(ErrWrite, err)
+	}
+
+	return nil
+}
+
 type NoteWithTag struct {
 	tag  gedcom.Tag
 	note string